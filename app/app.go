@@ -17,18 +17,23 @@ func Run() error {
 		return err
 	}
 
-	minioLoader, err := minio.Init(cfg.MinIO)
-	if err != nil {
-		return err
-	}
+	storages := make(server.Storages, len(cfg.Storages))
+	for _, storageCfg := range cfg.Storages {
+		minioLoader, err := minio.Init(storageCfg, cfg.Archive)
+		if err != nil {
+			return err
+		}
 
-	if err = minioLoader.CreateBucket(ctx, cfg.MinIO.Location); err != nil {
-		return err
-	}
+		if err = minioLoader.CreateBucket(ctx, storageCfg.Location); err != nil {
+			return err
+		}
+
+		go minioLoader.RunTrashPurge(ctx)
 
-	loader := load.Init(minioLoader)
+		storages[storageCfg.Storage] = load.Init(minioLoader)
+	}
 
-	server := server.Init(ctx, loader)
+	server := server.Init(ctx, storages, cfg.Upload)
 
 	if err := server.Start(cfg.App); err != nil { // тут внутри горутина
 		return err
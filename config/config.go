@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -25,11 +26,64 @@ type MinIOConfig struct {
 	BucketName      string
 	Location        string
 	Storage         string
+
+	// Мягкое удаление: объекты переносятся в корзину и удаляются
+	// окончательно после истечения TrashRetention
+	SoftDelete         bool
+	TrashPrefix        string
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	envPrefix string
+}
+
+// StoragesConfig описывает набор именованных хранилищ. Имена перечисляются
+// в MINIO_STORAGES, а параметры каждого берутся из переменных MINIO_<ИМЯ>_*.
+// Если MINIO_STORAGES не задана, используется одно хранилище из MINIO_*.
+type StoragesConfig struct {
+	Storages []MinIOConfig
+}
+
+// ArchiveConfig ограничивает работу с вложенными архивами и распаковку
+// загружаемых ZIP, задает размер кеша каталогов ZIP. Все переменные
+// ARCHIVE_* необязательны.
+type ArchiveConfig struct {
+	NestedMaxDepth    int
+	NestedMemoryLimit int64
+	NestedMaxSize     int64
+	ExpandMemoryLimit int64
+	ExpandMaxSize     int64
+	TempDir           string
+
+	DirectoryCacheSize     int
+	DirectoryCacheMaxFiles int
+}
+
+// Политики выбора Content-Type загружаемого файла
+const (
+	// ContentTypePolicySniff доверяет сигнатуре содержимого, а заявленный
+	// тип и расширение берет, только если они с ней согласуются
+	ContentTypePolicySniff = "sniff"
+	// ContentTypePolicyDeclared доверяет заголовку Content-Type клиента
+	ContentTypePolicyDeclared = "declared"
+	// ContentTypePolicyExtension доверяет расширению имени файла
+	ContentTypePolicyExtension = "extension"
+	// ContentTypePolicyStrict как sniff, но отклоняет загрузку, если
+	// заявленный тип или расширение противоречат содержимому
+	ContentTypePolicyStrict = "strict"
+)
+
+// UploadConfig задает обработку загружаемых файлов. Все переменные
+// UPLOAD_* необязательны.
+type UploadConfig struct {
+	ContentTypePolicy string
 }
 
 type Config struct {
-	App   AppConfig
-	MinIO MinIOConfig
+	App      AppConfig
+	Storages []MinIOConfig
+	Archive  ArchiveConfig
+	Upload   UploadConfig
 }
 
 func readEnv() (map[string]string, error) {
@@ -53,9 +107,11 @@ func Get() (Config, error) {
 	}
 
 	appCfg := &AppConfig{}
-	minioCfg := &MinIOConfig{}
+	storagesCfg := &StoragesConfig{}
+	archiveCfg := &ArchiveConfig{}
+	uploadCfg := &UploadConfig{}
 
-	configs := []BasicConfig{appCfg, minioCfg}
+	configs := []BasicConfig{appCfg, storagesCfg, archiveCfg, uploadCfg}
 	for _, cfg := range configs {
 		if err := cfg.Load(envMap); err != nil {
 			slog.Error("Ошибка при загрузке конфигурации", "error", err)
@@ -69,7 +125,9 @@ func Get() (Config, error) {
 
 	slog.Info("Все конфигурации успешно загружены")
 	return Config{
-		App:   *appCfg,
-		MinIO: *minioCfg,
+		App:      *appCfg,
+		Storages: storagesCfg.Storages,
+		Archive:  *archiveCfg,
+		Upload:   *uploadCfg,
 	}, nil
 }
@@ -4,45 +4,47 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func (mc *MinIOConfig) Load(envMap map[string]string) error {
 	var ok bool
 	var missingVars []string
 
-	mc.Endpoint, ok = envMap["MINIO_ENDPOINT"]
+	mc.Endpoint, ok = envMap[mc.envKey("ENDPOINT")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_ENDPOINT")
+		missingVars = append(missingVars, mc.envKey("ENDPOINT"))
 	}
 
-	mc.AccessKeyID, ok = envMap["MINIO_ACCESS_KEY"]
+	mc.AccessKeyID, ok = envMap[mc.envKey("ACCESS_KEY")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_ACCESS_KEY")
+		missingVars = append(missingVars, mc.envKey("ACCESS_KEY"))
 	}
 
-	mc.SecretAccessKey, ok = envMap["MINIO_SECRET_KEY"]
+	mc.SecretAccessKey, ok = envMap[mc.envKey("SECRET_KEY")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_SECRET_KEY")
+		missingVars = append(missingVars, mc.envKey("SECRET_KEY"))
 	}
 
-	mc.BucketName, ok = envMap["MINIO_BUCKET_NAME"]
+	mc.BucketName, ok = envMap[mc.envKey("BUCKET_NAME")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_BUCKET_NAME")
+		missingVars = append(missingVars, mc.envKey("BUCKET_NAME"))
 	}
 
-	mc.Location, ok = envMap["MINIO_LOCATION"]
+	mc.Location, ok = envMap[mc.envKey("LOCATION")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_LOCATION")
+		missingVars = append(missingVars, mc.envKey("LOCATION"))
 	}
 
-	mc.Storage, ok = envMap["MINIO_STORAGE"]
+	mc.Storage, ok = envMap[mc.envKey("STORAGE")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_STORAGE")
+		missingVars = append(missingVars, mc.envKey("STORAGE"))
 	}
 
-	useSSLStr, ok := envMap["MINIO_USE_SSL"]
+	useSSLStr, ok := envMap[mc.envKey("USE_SSL")]
 	if !ok {
-		missingVars = append(missingVars, "MINIO_USE_SSL")
+		missingVars = append(missingVars, mc.envKey("USE_SSL"))
 	} else {
 		mc.UseSSL = (useSSLStr == "true")
 	}
@@ -54,6 +56,80 @@ func (mc *MinIOConfig) Load(envMap map[string]string) error {
 		return fmt.Errorf("отсутствуют обязательные переменные окружения MINIO: %v", missingVars)
 	}
 
+	return mc.loadSoftDelete(envMap)
+}
+
+const (
+	defaultTrashPrefix        = ".trash/"
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = time.Hour
+)
+
+func (mc *MinIOConfig) loadSoftDelete(envMap map[string]string) error {
+	mc.SoftDelete = envMap[mc.envKey("SOFT_DELETE")] == "true"
+
+	mc.TrashPrefix = defaultTrashPrefix
+	if prefix, ok := envMap[mc.envKey("TRASH_PREFIX")]; ok {
+		mc.TrashPrefix = prefix
+	}
+
+	var err error
+	if mc.TrashRetention, err = loadDuration(envMap, mc.envKey("TRASH_RETENTION"), defaultTrashRetention); err != nil {
+		return err
+	}
+	if mc.TrashPurgeInterval, err = loadDuration(envMap, mc.envKey("TRASH_PURGE_INTERVAL"), defaultTrashPurgeInterval); err != nil {
+		return err
+	}
+	return nil
+}
+
+func loadDuration(envMap map[string]string, name string, defaultValue time.Duration) (time.Duration, error) {
+	valueStr, ok := envMap[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка преобразования %s в длительность: %w", name, err)
+	}
+	return value, nil
+}
+
+func (mc *MinIOConfig) envKey(name string) string {
+	if mc.envPrefix == "" {
+		return "MINIO_" + name
+	}
+	return mc.envPrefix + name
+}
+
+func (sc *StoragesConfig) Load(envMap map[string]string) error {
+	namesStr, ok := envMap["MINIO_STORAGES"]
+	if !ok {
+		storage := MinIOConfig{}
+		if err := storage.Load(envMap); err != nil {
+			return err
+		}
+		sc.Storages = []MinIOConfig{storage}
+		return nil
+	}
+
+	for _, name := range strings.Split(namesStr, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		storage := MinIOConfig{envPrefix: "MINIO_" + name + "_"}
+		if err := storage.Load(envMap); err != nil {
+			return err
+		}
+		sc.Storages = append(sc.Storages, storage)
+	}
+
+	if len(sc.Storages) == 0 {
+		return fmt.Errorf("переменная MINIO_STORAGES не содержит ни одного хранилища")
+	}
+
 	return nil
 }
 
@@ -95,3 +171,76 @@ func (ap *AppConfig) loadPort(envMap map[string]string) error {
 	ap.Port = port
 	return nil
 }
+
+const (
+	defaultNestedMaxDepth    = 4
+	defaultNestedMemoryLimit = 32 * 1024 * 1024
+	defaultNestedMaxSize     = 2 * 1024 * 1024 * 1024
+	defaultExpandMemoryLimit = 32 * 1024 * 1024
+	defaultExpandMaxSize     = 2 * 1024 * 1024 * 1024
+
+	defaultDirectoryCacheSize     = 64
+	defaultDirectoryCacheMaxFiles = 1_000_000
+)
+
+func (ac *ArchiveConfig) Load(envMap map[string]string) error {
+	var err error
+	if ac.NestedMaxDepth, err = loadInt(envMap, "ARCHIVE_NESTED_MAX_DEPTH", defaultNestedMaxDepth); err != nil {
+		return err
+	}
+
+	memoryLimit, err := loadInt(envMap, "ARCHIVE_NESTED_MEMORY_LIMIT", defaultNestedMemoryLimit)
+	if err != nil {
+		return err
+	}
+	ac.NestedMemoryLimit = int64(memoryLimit)
+
+	maxSize, err := loadInt(envMap, "ARCHIVE_NESTED_MAX_SIZE", defaultNestedMaxSize)
+	if err != nil {
+		return err
+	}
+	ac.NestedMaxSize = int64(maxSize)
+
+	expandMemoryLimit, err := loadInt(envMap, "ARCHIVE_EXPAND_MEMORY_LIMIT", defaultExpandMemoryLimit)
+	if err != nil {
+		return err
+	}
+	ac.ExpandMemoryLimit = int64(expandMemoryLimit)
+
+	expandMaxSize, err := loadInt(envMap, "ARCHIVE_EXPAND_MAX_SIZE", defaultExpandMaxSize)
+	if err != nil {
+		return err
+	}
+	ac.ExpandMaxSize = int64(expandMaxSize)
+
+	ac.TempDir = envMap["ARCHIVE_TEMP_DIR"]
+
+	if ac.DirectoryCacheSize, err = loadInt(envMap, "ARCHIVE_DIRECTORY_CACHE_SIZE", defaultDirectoryCacheSize); err != nil {
+		return err
+	}
+	if ac.DirectoryCacheMaxFiles, err = loadInt(envMap, "ARCHIVE_DIRECTORY_CACHE_MAX_FILES", defaultDirectoryCacheMaxFiles); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uc *UploadConfig) Load(envMap map[string]string) error {
+	uc.ContentTypePolicy = ContentTypePolicySniff
+	if policy, ok := envMap["UPLOAD_CONTENT_TYPE_POLICY"]; ok && policy != "" {
+		uc.ContentTypePolicy = strings.ToLower(strings.TrimSpace(policy))
+	}
+	return nil
+}
+
+func loadInt(envMap map[string]string, name string, defaultValue int) (int, error) {
+	valueStr, ok := envMap[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка преобразования %s в число: %w", name, err)
+	}
+	return value, nil
+}
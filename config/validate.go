@@ -12,22 +12,22 @@ func (mc *MinIOConfig) Validate() error {
 	missingVars := []string{}
 
 	if mc.Endpoint == "" {
-		missingVars = append(missingVars, "MINIO_ENDPOINT")
+		missingVars = append(missingVars, mc.envKey("ENDPOINT"))
 	}
 	if mc.AccessKeyID == "" {
-		missingVars = append(missingVars, "MINIO_ACCESS_KEY")
+		missingVars = append(missingVars, mc.envKey("ACCESS_KEY"))
 	}
 	if mc.SecretAccessKey == "" {
-		missingVars = append(missingVars, "MINIO_SECRET_KEY")
+		missingVars = append(missingVars, mc.envKey("SECRET_KEY"))
 	}
 	if mc.BucketName == "" {
-		missingVars = append(missingVars, "MINIO_BUCKET_NAME")
+		missingVars = append(missingVars, mc.envKey("BUCKET_NAME"))
 	}
 	if mc.Location == "" {
-		missingVars = append(missingVars, "MINIO_LOCATION")
+		missingVars = append(missingVars, mc.envKey("LOCATION"))
 	}
 	if mc.Storage == "" {
-		missingVars = append(missingVars, "MINIO_STORAGE")
+		missingVars = append(missingVars, mc.envKey("STORAGE"))
 	}
 
 	if len(missingVars) > 0 {
@@ -42,6 +42,41 @@ func (mc *MinIOConfig) Validate() error {
 		return errors.New(message)
 	}
 
+	if mc.SoftDelete {
+		return mc.validateSoftDelete()
+	}
+
+	return nil
+}
+
+func (mc *MinIOConfig) validateSoftDelete() error {
+	if mc.TrashPrefix == "" || !strings.HasSuffix(mc.TrashPrefix, "/") || strings.HasPrefix(mc.TrashPrefix, "/") {
+		return fmt.Errorf("%s должен быть непустым префиксом вида 'trash/', получено: '%s'", mc.envKey("TRASH_PREFIX"), mc.TrashPrefix)
+	}
+	if mc.TrashRetention <= 0 {
+		return fmt.Errorf("%s должен быть положительным, получено: %s", mc.envKey("TRASH_RETENTION"), mc.TrashRetention)
+	}
+	if mc.TrashPurgeInterval <= 0 {
+		return fmt.Errorf("%s должен быть положительным, получено: %s", mc.envKey("TRASH_PURGE_INTERVAL"), mc.TrashPurgeInterval)
+	}
+	return nil
+}
+
+func (sc *StoragesConfig) Validate() error {
+	seen := make(map[string]bool, len(sc.Storages))
+	for i := range sc.Storages {
+		if err := sc.Storages[i].Validate(); err != nil {
+			return err
+		}
+
+		name := sc.Storages[i].Storage
+		if seen[name] {
+			message := fmt.Sprintf("Хранилище '%s' объявлено несколько раз", name)
+			slog.Error(message)
+			return errors.New(message)
+		}
+		seen[name] = true
+	}
 	return nil
 }
 
@@ -57,3 +92,36 @@ func (ap *AppConfig) Validate() error {
 	}
 	return nil
 }
+
+func (ac *ArchiveConfig) Validate() error {
+	if ac.NestedMaxDepth < 0 {
+		return fmt.Errorf("ARCHIVE_NESTED_MAX_DEPTH не может быть отрицательным, получено: %d", ac.NestedMaxDepth)
+	}
+	if ac.NestedMemoryLimit < 0 {
+		return fmt.Errorf("ARCHIVE_NESTED_MEMORY_LIMIT не может быть отрицательным, получено: %d", ac.NestedMemoryLimit)
+	}
+	if ac.NestedMaxSize < ac.NestedMemoryLimit {
+		return fmt.Errorf("ARCHIVE_NESTED_MAX_SIZE (%d) должен быть не меньше ARCHIVE_NESTED_MEMORY_LIMIT (%d)", ac.NestedMaxSize, ac.NestedMemoryLimit)
+	}
+	if ac.ExpandMemoryLimit < 0 {
+		return fmt.Errorf("ARCHIVE_EXPAND_MEMORY_LIMIT не может быть отрицательным, получено: %d", ac.ExpandMemoryLimit)
+	}
+	if ac.ExpandMaxSize < ac.ExpandMemoryLimit {
+		return fmt.Errorf("ARCHIVE_EXPAND_MAX_SIZE (%d) должен быть не меньше ARCHIVE_EXPAND_MEMORY_LIMIT (%d)", ac.ExpandMaxSize, ac.ExpandMemoryLimit)
+	}
+	if ac.DirectoryCacheSize < 0 {
+		return fmt.Errorf("ARCHIVE_DIRECTORY_CACHE_SIZE не может быть отрицательным, получено: %d", ac.DirectoryCacheSize)
+	}
+	if ac.DirectoryCacheMaxFiles < 0 {
+		return fmt.Errorf("ARCHIVE_DIRECTORY_CACHE_MAX_FILES не может быть отрицательным, получено: %d", ac.DirectoryCacheMaxFiles)
+	}
+	return nil
+}
+
+func (uc *UploadConfig) Validate() error {
+	switch uc.ContentTypePolicy {
+	case ContentTypePolicySniff, ContentTypePolicyDeclared, ContentTypePolicyExtension, ContentTypePolicyStrict:
+		return nil
+	}
+	return fmt.Errorf("UPLOAD_CONTENT_TYPE_POLICY должна быть одной из sniff, declared, extension, strict, получено: %q", uc.ContentTypePolicy)
+}
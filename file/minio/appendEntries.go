@@ -0,0 +1,155 @@
+package minio
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"s3_multiclient/server"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// stagingPrefix — служебная область для объектов, которые еще пишутся.
+// Если процесс упал до удаления, объект остается здесь и не виден
+// через обычные маршруты.
+const stagingPrefix = ".staging/"
+
+// AppendArchiveEntries пересобирает хранимый ZIP с новыми элементами.
+// Неизмененные элементы переносятся без распаковки, новый архив пишется
+// во временный объект и копируется на место исходного условной записью:
+// только если исходный архив за это время не изменился.
+func (ml *MinioLoader) AppendArchiveEntries(ctx context.Context, data *server.AppendEntriesRequestMetadata) error {
+	slog.Info("Начало изменения архива", "object_id", data.ID, "entries_quantity", len(data.Entries))
+
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+	for _, entry := range data.Entries {
+		if err := validateEntryPath(entry.Path); err != nil {
+			return err
+		}
+	}
+
+	info, err := ml.statObject(ctx, data.Key)
+	if err != nil {
+		return err
+	}
+	if kind := ml.detectContainer(ctx, info); kind != containerZip {
+		return fmt.Errorf("%w: дописывать элементы можно только в ZIP, получено %q", server.ErrNotArchive, kind)
+	}
+
+	archive, err := ml.openZip(ctx, info, "")
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	stagingKey := stagingPrefix + data.Key + "." + uuid.NewString()
+	uploaded, count, err := ml.writeUpdatedZip(ctx, archive, data.Entries, stagingKey, info.ContentType)
+	if err != nil {
+		slog.Error("Не удалось записать измененный архив", "key", data.Key, "error", err)
+		return fmt.Errorf("не удалось записать измененный архив: %w", err)
+	}
+	defer func() {
+		if err := ml.client.RemoveObject(context.WithoutCancel(ctx), ml.bucketName, stagingKey, minio.RemoveObjectOptions{}); err != nil {
+			slog.Warn("Не удалось удалить временный архив", "staging_key", stagingKey, "error", err)
+		}
+	}()
+
+	userMetadata := maps.Clone(info.UserMetadata)
+	if userMetadata == nil {
+		userMetadata = make(map[string]string, 2)
+	}
+	userMetadata[uploadedAtKey] = time.Now().Format(time.RFC3339)
+	userMetadata[containerKindKey] = string(containerZip)
+
+	// Копирование с If-Match: если архив изменился, пока собирался новый,
+	// хранилище откажет, и параллельная запись не будет потеряна
+	err = ml.copyObject(ctx, uploaded, data.Key, userMetadata, copyCondition{matchETag: info.ETag})
+	if isPreconditionFailed(err) {
+		return fmt.Errorf("%w: %s", server.ErrObjectChanged, data.Key)
+	}
+	if err != nil {
+		slog.Error("Не удалось заменить архив", "key", data.Key, "error", err)
+		return fmt.Errorf("не удалось заменить архив: %w", err)
+	}
+
+	data.FileName = determineFileName(info)
+	data.ContentType = info.ContentType
+	data.Size = uploaded.Size
+
+	slog.Info("Архив изменен", "object_id", data.ID, "entries_quantity", count, "size", uploaded.Size)
+	return nil
+}
+
+// writeUpdatedZip потоком пишет новый архив в stagingKey: сначала элементы
+// исходного архива, кроме заменяемых, затем переданные файлы
+func (ml *MinioLoader) writeUpdatedZip(ctx context.Context, archive *zipArchive, entries []*server.AppendedEntry, stagingKey, contentType string) (minio.ObjectInfo, int, error) {
+	replaced := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		replaced[entry.Path] = true
+	}
+
+	reader, writer := io.Pipe()
+	count := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		zw := zip.NewWriter(writer)
+		err := func() error {
+			for _, entry := range archive.dir.entries {
+				if replaced[entry.Name] {
+					continue
+				}
+				if err := archive.copyRaw(zw, entry); err != nil {
+					return fmt.Errorf("ошибка при переносе элемента %s: %w", entry.Name, err)
+				}
+				count++
+			}
+			for _, entry := range entries {
+				if err := writeAppendedEntry(zw, entry); err != nil {
+					return err
+				}
+				count++
+			}
+			return zw.Close()
+		}()
+		writer.CloseWithError(err)
+	}()
+
+	uploaded, err := ml.client.PutObject(ctx, ml.bucketName, stagingKey, reader, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    uploadChunkSize,
+	})
+	reader.CloseWithError(err)
+	<-done
+	if err != nil {
+		return minio.ObjectInfo{}, 0, err
+	}
+
+	return minio.ObjectInfo{Key: stagingKey, ContentType: contentType, Size: uploaded.Size}, count, nil
+}
+
+func writeAppendedEntry(zw *zip.Writer, entry *server.AppendedEntry) error {
+	content, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("не удалось открыть файл %s: %w", entry.Path, err)
+	}
+	defer content.Close()
+
+	header := &zip.FileHeader{Name: entry.Path, Method: zip.Deflate, Modified: time.Now()}
+	writer, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("ошибка при добавлении элемента %s: %w", entry.Path, err)
+	}
+	if _, err := io.Copy(writer, content); err != nil {
+		return fmt.Errorf("ошибка при записи элемента %s: %w", entry.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"s3_multiclient/file/sniff"
+	"s3_multiclient/server"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// containerKind — формат архива, из которого можно извлекать элементы
+type containerKind string
+
+const (
+	containerNone   containerKind = sniff.ContainerNone
+	containerZip    containerKind = sniff.ContainerZip
+	containerTar    containerKind = sniff.ContainerTar
+	containerTarGz  containerKind = sniff.ContainerTarGz
+	containerTarZst containerKind = sniff.ContainerTarZst
+)
+
+// memberArchive — архив, открытый для выбора и чтения элементов
+type memberArchive interface {
+	// Entries возвращает каталог архива с CRC32 всех элементов
+	Entries() ([]*server.ArchiveEntry, error)
+	// Select находит единственный элемент, подходящий под селектор
+	Select(selector *server.MemberSelector) (*server.ArchiveEntry, error)
+	// Open открывает элемент, найденный Select или Entries, с начала part
+	Open(entry *server.ArchiveEntry, part *server.ContentRange) (io.ReadCloser, error)
+	Close()
+}
+
+// detectContainer определяет формат архива. Приоритет у формата, сохраненного
+// при загрузке, затем у сигнатуры первых байт объекта. Content-Type и имя
+// файла используются как подсказка, если сигнатура не распознана, например
+// у самораспаковывающихся ZIP с заголовком перед архивом.
+func (ml *MinioLoader) detectContainer(ctx context.Context, info minio.ObjectInfo) containerKind {
+	if kind, ok := info.UserMetadata[containerKindKey]; ok {
+		return containerKind(kind)
+	}
+
+	prefix, err := ml.readPrefix(ctx, info, sniff.PrefixSize)
+	if err != nil {
+		slog.Warn("Не удалось прочитать начало объекта для определения формата", "key", info.Key, "error", err)
+	} else if kind := sniff.DetectContainer(prefix); kind != sniff.ContainerNone {
+		return containerKind(kind)
+	}
+
+	return containerHint(info.ContentType, determineFileName(info))
+}
+
+func (ml *MinioLoader) readPrefix(ctx context.Context, info minio.ObjectInfo, n int64) ([]byte, error) {
+	if info.Size == 0 {
+		return nil, nil
+	}
+
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(0, min(n, info.Size)-1); err != nil {
+		return nil, err
+	}
+
+	minioObject, err := ml.getObject(ctx, info, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer minioObject.reader.Close()
+
+	return io.ReadAll(minioObject.reader)
+}
+
+// containerHint определяет формат архива по Content-Type и имени файла
+func containerHint(contentType, name string) containerKind {
+	name = strings.ToLower(name)
+
+	switch contentType {
+	case "application/zip", "application/x-zip-compressed", "application/java-archive":
+		return containerZip
+	case "application/x-tar", "application/tar":
+		return containerTar
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return containerZip
+	case strings.HasSuffix(name, ".tar"):
+		return containerTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return containerTarGz
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return containerTarZst
+	}
+	return containerNone
+}
+
+func (ml *MinioLoader) openArchive(ctx context.Context, info minio.ObjectInfo, password server.ArchivePassword) (memberArchive, containerKind, error) {
+	kind := ml.detectContainer(ctx, info)
+
+	switch kind {
+	case containerZip:
+		archive, err := ml.openZip(ctx, info, password)
+		return archive, kind, err
+	case containerTar, containerTarGz, containerTarZst:
+		return ml.openTar(ctx, info, kind), kind, nil
+	default:
+		return nil, kind, fmt.Errorf("%w: key=%s, content_type=%s", server.ErrNotArchive, info.Key, info.ContentType)
+	}
+}
+
+// memberETag строит ETag элемента архива из ETag архива и номеров элементов
+// на каждом уровне вложенности
+func memberETag(archiveETag string, indexes []int) string {
+	if archiveETag == "" {
+		return ""
+	}
+
+	etag := strings.Trim(archiveETag, `"`)
+	for _, index := range indexes {
+		etag += "-" + strconv.Itoa(index)
+	}
+	return server.QuoteETag(etag)
+}
+
+// skipToRange отбрасывает байты до начала диапазона в потоковом элементе
+func skipToRange(rc io.ReadCloser, part *server.ContentRange) (io.ReadCloser, error) {
+	if part == nil || part.Start == 0 {
+		return rc, nil
+	}
+	if _, err := io.CopyN(io.Discard, rc, part.Start); err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("не удалось перейти к началу диапазона: %w", err)
+	}
+	return rc, nil
+}
@@ -0,0 +1,39 @@
+package minio
+
+import (
+	"context"
+	"log/slog"
+	"s3_multiclient/server"
+)
+
+func (ml *MinioLoader) ListArchiveEntries(ctx context.Context, data *server.DownloadRequestMetadata) ([]*server.ArchiveEntry, error) {
+	slog.Info("Начало чтения каталога архива", "object_id", data.ID)
+
+	info, err := ml.statObject(ctx, data.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		archive memberArchive
+		kind    containerKind
+	)
+	if data.Member == nil {
+		archive, kind, err = ml.openArchive(ctx, info, data.Password)
+	} else {
+		// Селектор указывает на вложенный архив, каталог которого нужно вернуть
+		archive, kind, err = ml.openMemberArchive(ctx, info, data.Member, data.Password)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	entries, err := archive.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Каталог архива прочитан", "object_id", data.ID, "kind", kind, "files_quantity", len(entries))
+	return entries, nil
+}
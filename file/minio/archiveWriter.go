@@ -0,0 +1,85 @@
+package minio
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"s3_multiclient/server"
+	"time"
+)
+
+// archiveWriter последовательно пишет файлы в потоковый архив
+type archiveWriter interface {
+	writeFile(name string, size int64, modified time.Time, content io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(format string, w io.Writer) (archiveWriter, error) {
+	switch format {
+	case server.ArchiveFormatZip:
+		return &zipArchiveWriter{zipWriter: zip.NewWriter(w)}, nil
+	case server.ArchiveFormatTarGz:
+		gzipWriter := gzip.NewWriter(w)
+		return &tarGzArchiveWriter{gzipWriter: gzipWriter, tarWriter: tar.NewWriter(gzipWriter)}, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат архива: %s", format)
+	}
+}
+
+type zipArchiveWriter struct {
+	zipWriter *zip.Writer
+}
+
+func (zw *zipArchiveWriter) writeFile(name string, _ int64, modified time.Time, content io.Reader) error {
+	entry, err := zw.zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось добавить %s в архив: %w", name, err)
+	}
+
+	if _, err := io.Copy(entry, content); err != nil {
+		return fmt.Errorf("ошибка записи %s в архив: %w", name, err)
+	}
+	return nil
+}
+
+func (zw *zipArchiveWriter) Close() error {
+	return zw.zipWriter.Close()
+}
+
+// tarGzArchiveWriter требует заранее известный размер каждого файла
+type tarGzArchiveWriter struct {
+	gzipWriter *gzip.Writer
+	tarWriter  *tar.Writer
+}
+
+func (tw *tarGzArchiveWriter) writeFile(name string, size int64, modified time.Time, content io.Reader) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  modified,
+		Format:   tar.FormatPAX,
+	}
+	if err := tw.tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("не удалось добавить %s в архив: %w", name, err)
+	}
+
+	if _, err := io.CopyN(tw.tarWriter, content, size); err != nil {
+		return fmt.Errorf("ошибка записи %s в архив: %w", name, err)
+	}
+	return nil
+}
+
+func (tw *tarGzArchiveWriter) Close() error {
+	if err := tw.tarWriter.Close(); err != nil {
+		return err
+	}
+	return tw.gzipWriter.Close()
+}
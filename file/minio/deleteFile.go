@@ -1,7 +1,41 @@
 package minio
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"s3_multiclient/server"
 
-func (ml *MinioLoader) DeleteFile(ctx context.Context, objectID string) error {
+	"github.com/minio/minio-go/v7"
+)
+
+func (ml *MinioLoader) DeleteFile(ctx context.Context, data *server.DeleteRequestMetadata) error {
+	slog.Info("Начало удаления объекта", "object_id", data.ID, "key", data.Key, "version_id", data.VersionID)
+
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	// RemoveObject не сообщает об отсутствии объекта, поэтому проверяем его заранее
+	stat, err := ml.client.StatObject(ctx, ml.bucketName, data.Key, minio.StatObjectOptions{VersionID: data.VersionID})
+	if err != nil {
+		if isObjectNotFound(err) {
+			slog.Warn("Удаляемый объект не найден", "key", data.Key, "version_id", data.VersionID)
+			return fmt.Errorf("%w: %s", server.ErrObjectNotFound, data.Key)
+		}
+		slog.Error("Не удалось получить метаданные объекта", "key", data.Key, "error", err)
+		return fmt.Errorf("не удалось получить метаданные объекта: %w", err)
+	}
+
+	if ml.trash.enabled {
+		return ml.moveToTrash(ctx, stat)
+	}
+
+	if err := ml.client.RemoveObject(ctx, ml.bucketName, data.Key, minio.RemoveObjectOptions{VersionID: data.VersionID}); err != nil {
+		slog.Error("Ошибка удаления объекта из MinIO", "key", data.Key, "error", err)
+		return fmt.Errorf("ошибка при удалении объекта из MinIO: %w", err)
+	}
+
+	slog.Info("Объект успешно удален из MinIO", "object_id", data.ID, "key", data.Key)
 	return nil
 }
@@ -0,0 +1,144 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"s3_multiclient/server"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	batchDeleteStatWorkers = 16
+	// removeBatchSize — предел S3 на число ключей в одном DeleteObjects
+	removeBatchSize = 1000
+)
+
+// DeleteFiles удаляет объекты пачками до 1000 ключей. S3 не сообщает
+// об отсутствии удаляемого ключа, поэтому существование объектов проверяется
+// заранее, а в пачки попадают только найденные. При включенном
+// мягком удалении найденные объекты сразу переносятся в корзину.
+func (ml *MinioLoader) DeleteFiles(ctx context.Context, data *server.BatchDeleteRequestMetadata) error {
+	slog.Info("Начало пакетного удаления объектов", "objects_quantity", len(data.Objects))
+
+	byKey := make(map[string][]*server.BatchDeleteObject, len(data.Objects))
+	for _, object := range data.Objects {
+		if object.Status != "" {
+			continue
+		}
+		byKey[object.Key] = append(byKey[object.Key], object)
+	}
+
+	objectsCh := make(chan minio.ObjectInfo)
+	go ml.statObjectsForRemoval(ctx, byKey, objectsCh)
+
+	deleted := 0
+	batch := make([]string, 0, removeBatchSize)
+	for object := range objectsCh {
+		batch = append(batch, object.Key)
+		if len(batch) == removeBatchSize {
+			deleted += ml.removeBatch(ctx, byKey, batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		deleted += ml.removeBatch(ctx, byKey, batch)
+	}
+
+	slog.Info("Пакетное удаление завершено", "requested", len(data.Objects), "deleted", deleted)
+	return nil
+}
+
+// removeBatch удаляет одну пачку, которую RemoveObjects отправит одним
+// запросом, и возвращает число удаленных ключей. Ошибка без имени объекта
+// означает, что запрос отклонен целиком. S3 сообщает только о неудачах,
+// поэтому ключи этой пачки без своего результата получают статус error.
+func (ml *MinioLoader) removeBatch(ctx context.Context, byKey map[string][]*server.BatchDeleteObject, keys []string) int {
+	objectsCh := make(chan minio.ObjectInfo, len(keys))
+	for _, key := range keys {
+		objectsCh <- minio.ObjectInfo{Key: key}
+	}
+	close(objectsCh)
+
+	var batchErr error
+	for removeErr := range ml.client.RemoveObjects(ctx, ml.bucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		if removeErr.ObjectName == "" {
+			slog.Error("Ошибка пакетного удаления объектов", "error", removeErr.Err)
+			batchErr = fmt.Errorf("результат удаления неизвестен: %w", removeErr.Err)
+			continue
+		}
+		slog.Error("Не удалось удалить объект", "key", removeErr.ObjectName, "error", removeErr.Err)
+		setDeleteStatus(byKey[removeErr.ObjectName], server.DeleteStatusError, removeErr.Err)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		objects := byKey[key]
+		if objects[0].Status != "" {
+			continue
+		}
+		if batchErr != nil {
+			setDeleteStatus(objects, server.DeleteStatusError, batchErr)
+			continue
+		}
+		setDeleteStatus(objects, server.DeleteStatusDeleted, nil)
+		deleted++
+	}
+	return deleted
+}
+
+func (ml *MinioLoader) statObjectsForRemoval(ctx context.Context, byKey map[string][]*server.BatchDeleteObject, objectsCh chan<- minio.ObjectInfo) {
+	defer close(objectsCh)
+
+	keys := make(chan string)
+	var wg sync.WaitGroup
+	for range batchDeleteStatWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				if err := ml.checkKey(key); err != nil {
+					setDeleteStatus(byKey[key], server.DeleteStatusError, err)
+					continue
+				}
+
+				stat, err := ml.client.StatObject(ctx, ml.bucketName, key, minio.StatObjectOptions{})
+				switch {
+				case err == nil && ml.trash.enabled:
+					if err := ml.moveToTrash(ctx, stat); err != nil {
+						setDeleteStatus(byKey[key], server.DeleteStatusError, err)
+					} else {
+						setDeleteStatus(byKey[key], server.DeleteStatusDeleted, nil)
+					}
+				case err == nil:
+					select {
+					case objectsCh <- minio.ObjectInfo{Key: key}:
+					case <-ctx.Done():
+						setDeleteStatus(byKey[key], server.DeleteStatusError, ctx.Err())
+					}
+				case isObjectNotFound(err):
+					setDeleteStatus(byKey[key], server.DeleteStatusNotFound, nil)
+				default:
+					setDeleteStatus(byKey[key], server.DeleteStatusError, err)
+				}
+			}
+		}()
+	}
+
+	for key := range byKey {
+		keys <- key
+	}
+	close(keys)
+	wg.Wait()
+}
+
+func setDeleteStatus(objects []*server.BatchDeleteObject, status string, err error) {
+	for _, object := range objects {
+		object.Status = status
+		if err != nil {
+			object.Error = err.Error()
+		}
+	}
+}
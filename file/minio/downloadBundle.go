@@ -0,0 +1,163 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"s3_multiclient/load"
+	"s3_multiclient/server"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// bundleItem — проверенный объект или элемент архива. Содержимое
+// открывается только при записи в архив.
+type bundleItem struct {
+	name     string
+	size     int64
+	modified time.Time
+	open     func() (io.ReadCloser, error)
+}
+
+// DownloadBundle собирает ZIP из нескольких объектов прямо в ответ клиенту.
+// Все объекты и элементы архивов проверяются до отправки заголовков, чтобы
+// ошибки вида 404 и 409 вернулись клиенту обычным ответом. Открытым в
+// каждый момент остается только записываемый элемент.
+func (ml *MinioLoader) DownloadBundle(ctx context.Context, pw *load.ProgressWriter, data *server.BundleRequestMetadata) error {
+	slog.Info("Начало сборки архива из объектов", "objects_quantity", len(data.Items), "name", data.Name)
+
+	items := make([]*bundleItem, 0, len(data.Items))
+	names := newUniqueNames()
+	for _, itemData := range data.Items {
+		item, err := ml.prepareBundleItem(ctx, itemData)
+		if err != nil {
+			return err
+		}
+		item.name = names.next(item.name)
+		items = append(items, item)
+	}
+
+	pw.Header().Set("Content-Type", "application/zip")
+	pw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, data.Name))
+	pw.WriteHeader(http.StatusOK)
+
+	writer, err := newArchiveWriter(server.ArchiveFormatZip, pw)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := writeBundleItem(writer, item); err != nil {
+			return fmt.Errorf("%w: %v", server.ErrStreamInterrupted, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("%w: ошибка завершения ZIP-архива: %v", server.ErrStreamInterrupted, err)
+	}
+
+	slog.Info("Архив из объектов отправлен клиенту", "name", data.Name, "total_bytes", pw.Total)
+	return nil
+}
+
+// prepareBundleItem проверяет объект и селектор элемента. Найденный элемент
+// сразу закрывается, а при записи открывается заново по тем же метаданным:
+// чтение привязано к ETag, поэтому подмена объекта даст ошибку, а не
+// другое содержимое.
+func (ml *MinioLoader) prepareBundleItem(ctx context.Context, data *server.DownloadRequestMetadata) (*bundleItem, error) {
+	info, err := ml.statObject(ctx, data.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Member == nil {
+		return &bundleItem{
+			name:     determineFileName(info),
+			size:     info.Size,
+			modified: info.LastModified,
+			open: func() (io.ReadCloser, error) {
+				object, err := ml.getObject(ctx, info, minio.GetObjectOptions{})
+				if err != nil {
+					return nil, err
+				}
+				return object.reader, nil
+			},
+		}, nil
+	}
+
+	member, err := ml.openMember(ctx, info, data.Member, data.Password)
+	if err != nil {
+		return nil, err
+	}
+	entry := member.entry
+	member.Close()
+
+	return &bundleItem{
+		name:     path.Base(entry.Name),
+		size:     entry.UncompressedSize,
+		modified: entry.Modified,
+		open: func() (io.ReadCloser, error) {
+			member, err := ml.openMember(ctx, info, data.Member, data.Password)
+			if err != nil {
+				return nil, err
+			}
+			content, err := member.archive.Open(member.entry, nil)
+			if err != nil {
+				member.Close()
+				return nil, err
+			}
+			return &memberReader{ReadCloser: content, member: member}, nil
+		},
+	}, nil
+}
+
+// memberReader вместе с содержимым элемента закрывает и его архивы
+type memberReader struct {
+	io.ReadCloser
+	member *openedMember
+}
+
+func (mr *memberReader) Close() error {
+	err := mr.ReadCloser.Close()
+	mr.member.Close()
+	return err
+}
+
+func writeBundleItem(writer archiveWriter, item *bundleItem) error {
+	content, err := item.open()
+	if err != nil {
+		return fmt.Errorf("не удалось открыть %s: %w", item.name, err)
+	}
+	defer content.Close()
+
+	return writer.writeFile(item.name, item.size, item.modified, content)
+}
+
+// uniqueNames выдает имена без повторов: report.csv, report (1).csv, ...
+type uniqueNames struct {
+	used map[string]bool
+}
+
+func newUniqueNames() *uniqueNames {
+	return &uniqueNames{used: make(map[string]bool)}
+}
+
+func (un *uniqueNames) next(name string) string {
+	if !un.used[name] {
+		un.used[name] = true
+		return name
+	}
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !un.used[candidate] {
+			un.used[candidate] = true
+			return candidate
+		}
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"s3_multiclient/load"
 	"s3_multiclient/server"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 )
@@ -13,26 +15,53 @@ import (
 func (ml *MinioLoader) DownloadFile(ctx context.Context, pw *load.ProgressWriter, data *server.DownloadRequestMetadata) error {
 	slog.Info("Начало обработки запроса на скачивание", "object_id", data.ID)
 
-	minioObject, err := ml.getObjectAndMetadata(ctx, data.ID)
+	info, err := ml.statObject(ctx, data.Key)
 	if err != nil {
 		return err
 	}
 
-	object := &downloadedFileData{
-		metadata:    data,
-		minioObject: *minioObject,
-	}
+	pw.Header().Set("Accept-Ranges", "bytes")
 
-	if object.minioObject.info.ContentType == "application/zip" {
-		if object.metadata.CRC32 == 0 {
-			return fmt.Errorf("отсутствует CRC для ZIP: object_id=%s", object.metadata.ID)
+	if data.Member != nil {
+		member, err := ml.openMember(ctx, info, data.Member, data.Password)
+		if err != nil {
+			return err
 		}
-		if err := streamFileFromZip(pw, object); err != nil {
-			return fmt.Errorf("ошибка при обработке ZIP-файла: %w", err)
+		defer member.Close()
+
+		if err := streamArchiveMember(pw, member, data, info); err != nil {
+			return fmt.Errorf("ошибка при обработке архива %s: %w", member.kind, err)
 		}
 		return nil
 	}
 
+	etag := server.QuoteETag(info.ETag)
+	if err := checkConditions(pw, data, etag, info.LastModified); err != nil {
+		return err
+	}
+
+	part, err := resolveRange(pw, data.RequestedRange(etag, info.LastModified), info.Size)
+	if err != nil {
+		return err
+	}
+
+	opts := minio.GetObjectOptions{}
+	if part != nil {
+		if err := opts.SetRange(part.Start, part.End()); err != nil {
+			return fmt.Errorf("не удалось задать диапазон чтения: %w", err)
+		}
+	}
+
+	minioObject, err := ml.getObject(ctx, info, opts)
+	if err != nil {
+		return err
+	}
+
+	object := &downloadedFileData{
+		metadata:    data,
+		minioObject: *minioObject,
+		part:        part,
+	}
 	if err := streamRegularFile(pw, object); err != nil {
 		return fmt.Errorf("ошибка при обработке обычного файла: %w", err)
 	}
@@ -40,27 +69,77 @@ func (ml *MinioLoader) DownloadFile(ctx context.Context, pw *load.ProgressWriter
 	return nil
 }
 
-func (ml *MinioLoader) getObjectAndMetadata(ctx context.Context, objectID string) (*minioFileObject, error) {
-	content, err := ml.client.GetObject(ctx, ml.bucketName, objectID, minio.GetObjectOptions{})
+func (ml *MinioLoader) statObject(ctx context.Context, key string) (minio.ObjectInfo, error) {
+	if err := ml.checkKey(key); err != nil {
+		return minio.ObjectInfo{}, err
+	}
+
+	stat, err := ml.client.StatObject(ctx, ml.bucketName, key, minio.StatObjectOptions{})
 	if err != nil {
-		slog.Error("Не удалось получить объект из MinIO", "object_id", objectID, "error", err)
-		return nil, fmt.Errorf("не удалось получить объект: %w", err)
+		slog.Error("Не удалось получить метаданные объекта", "key", key, "error", err)
+		if isObjectNotFound(err) {
+			return minio.ObjectInfo{}, fmt.Errorf("%w: %s", server.ErrObjectNotFound, key)
+		}
+		return minio.ObjectInfo{}, fmt.Errorf("не удалось получить метаданные объекта: %w", err)
+	}
+
+	return stat, nil
+}
+
+func (ml *MinioLoader) getObject(ctx context.Context, info minio.ObjectInfo, opts minio.GetObjectOptions) (*minioFileObject, error) {
+	// Привязываем чтение к версии, для которой получены метаданные
+	if info.ETag != "" {
+		if err := opts.SetMatchETag(info.ETag); err != nil {
+			return nil, fmt.Errorf("не удалось задать ETag для чтения: %w", err)
+		}
 	}
 
-	stat, err := ml.client.StatObject(ctx, ml.bucketName, objectID, minio.StatObjectOptions{})
+	content, err := ml.client.GetObject(ctx, ml.bucketName, info.Key, opts)
 	if err != nil {
-		slog.Error("Не удалось получить метаданные объекта", "object_id", objectID, "error", err)
-		return nil, fmt.Errorf("не удалось получить метаданные объекта: %w", err)
+		slog.Error("Не удалось получить объект из MinIO", "key", info.Key, "error", err)
+		return nil, fmt.Errorf("не удалось получить объект: %w", err)
 	}
 
 	object := &minioFileObject{
 		reader: content,
-		info:   stat,
+		info:   info,
 	}
 
 	return object, nil
 }
 
+// checkConditions выставляет валидаторы кеша и проверяет условные заголовки
+func checkConditions(pw *load.ProgressWriter, data *server.DownloadRequestMetadata, etag string, lastModified time.Time) error {
+	if etag != "" {
+		pw.Header().Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		pw.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if data.Conditions.NotModified(etag, lastModified) {
+		slog.Info("Объект не изменялся, отправляется 304", "object_id", data.ID, "etag", etag)
+		return server.ErrNotModified
+	}
+	return nil
+}
+
+// resolveRange приводит запрошенный диапазон к размеру содержимого.
+// Для недостижимого диапазона выставляет Content-Range для ответа 416.
+func resolveRange(pw *load.ProgressWriter, requested *server.ByteRange, size int64) (*server.ContentRange, error) {
+	if requested == nil {
+		return nil, nil
+	}
+
+	part, err := requested.Resolve(size)
+	if err != nil {
+		pw.Header().Set("Content-Range", server.UnsatisfiedRangeHeader(size))
+		slog.Warn("Запрошен недостижимый диапазон", "error", err)
+		return nil, err
+	}
+	return part, nil
+}
+
 // Сообщения о статусе скачивания
 
 // type FileDownloadResult struct {
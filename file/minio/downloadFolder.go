@@ -0,0 +1,91 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"s3_multiclient/load"
+	"s3_multiclient/server"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// DownloadFolder выгружает все объекты под префиксом одним архивом.
+// Структура каталогов сохраняется относительно префикса, а имена файлов
+// берутся из X-Original-Name.
+func (ml *MinioLoader) DownloadFolder(ctx context.Context, pw *load.ProgressWriter, data *server.FolderRequestMetadata) error {
+	slog.Info("Начало выгрузки папки", "prefix", data.Prefix, "format", data.Format)
+
+	if err := ml.checkKey(data.Prefix); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listed := ml.client.ListObjects(ctx, ml.bucketName, minio.ListObjectsOptions{Prefix: data.Prefix, Recursive: true})
+
+	// Первый объект читаем до отправки заголовков, чтобы пустая папка дала 404
+	first, ok := <-listed
+	if !ok {
+		return fmt.Errorf("%w: под префиксом %s нет объектов", server.ErrObjectNotFound, data.Prefix)
+	}
+	if first.Err != nil {
+		return fmt.Errorf("ошибка листинга объектов: %w", first.Err)
+	}
+
+	writer, err := newArchiveWriter(data.Format, pw)
+	if err != nil {
+		return err
+	}
+
+	pw.Header().Set("Content-Type", server.ArchiveContentType(data.Format))
+	pw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, data.Name))
+	pw.WriteHeader(http.StatusOK)
+
+	names := newUniqueNames()
+	count := 0
+	for object := first; ; {
+		if object.Err != nil {
+			return fmt.Errorf("%w: ошибка листинга объектов: %v", server.ErrStreamInterrupted, object.Err)
+		}
+
+		if err := ml.writeFolderObject(ctx, writer, names, data.Prefix, object); err != nil {
+			return fmt.Errorf("%w: %v", server.ErrStreamInterrupted, err)
+		}
+		count++
+
+		if object, ok = <-listed; !ok {
+			break
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("%w: ошибка завершения архива: %v", server.ErrStreamInterrupted, err)
+	}
+
+	slog.Info("Папка выгружена", "prefix", data.Prefix, "objects_quantity", count, "total_bytes", pw.Total)
+	return nil
+}
+
+func (ml *MinioLoader) writeFolderObject(ctx context.Context, writer archiveWriter, names *uniqueNames, prefix string, listed minio.ObjectInfo) error {
+	content, err := ml.client.GetObject(ctx, ml.bucketName, listed.Key, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("не удалось получить объект %s: %w", listed.Key, err)
+	}
+	defer content.Close()
+
+	// Stat берет метаданные из ответа на тот же GET, лишнего запроса нет
+	info, err := content.Stat()
+	if err != nil {
+		return fmt.Errorf("не удалось получить метаданные объекта %s: %w", listed.Key, err)
+	}
+
+	dir := path.Dir(strings.TrimPrefix(listed.Key, prefix))
+	name := names.next(path.Join(dir, determineFileName(info)))
+
+	return writer.writeFile(name, info.Size, info.LastModified, content)
+}
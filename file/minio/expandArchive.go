@@ -0,0 +1,216 @@
+package minio
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"path"
+	"s3_multiclient/server"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	maxExpandedObjects = 10000
+	entryPathKey       = "X-Entry-Path"
+)
+
+// expandArchive распаковывает загружаемый архив: каждый файл сохраняется
+// отдельным объектом с ключом Key/<uuid>, а в data.Manifest записывается,
+// из какого элемента он получен. Каталоги и ссылки пропускаются. При ошибке
+// уже созданные объекты удаляются.
+func (ml *MinioLoader) expandArchive(ctx context.Context, content io.Reader, data *server.UploadRequestMetadata) error {
+	slog.Info("Начало распаковки загружаемого архива", "object_id", data.ID, "kind", data.ContainerKind)
+
+	var err error
+	switch kind := containerKind(data.ContainerKind); kind {
+	case containerZip:
+		err = ml.expandZip(ctx, content, data)
+	case containerTar, containerTarGz, containerTarZst:
+		err = ml.expandTar(ctx, content, kind, data)
+	default:
+		err = fmt.Errorf("%w: формат %q", server.ErrNotArchive, kind)
+	}
+
+	if err != nil {
+		slog.Error("Распаковка архива прервана", "object_id", data.ID, "error", err)
+		ml.removeExpanded(context.WithoutCancel(ctx), data.Manifest)
+		data.Manifest = nil
+		return err
+	}
+
+	slog.Info("Архив распакован", "object_id", data.ID, "objects_quantity", len(data.Manifest))
+	return nil
+}
+
+// expandZip сохраняет архив во временный буфер, так как zip.Reader нужен
+// произвольный доступ. Пути всех элементов проверяются до записи первого объекта.
+func (ml *MinioLoader) expandZip(ctx context.Context, content io.Reader, data *server.UploadRequestMetadata) error {
+	source, size, cleanup, err := ml.spool(content, data.Size, ml.archive.ExpandMemoryLimit, ml.archive.ExpandMaxSize)
+	if err != nil {
+		return err
+	}
+
+	archive, err := newZipArchive(source, size, data.Password, cleanup)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	entries, err := archive.Entries()
+	if err != nil {
+		return err
+	}
+	if len(entries) > maxExpandedObjects {
+		return fmt.Errorf("%w: в архиве больше %d элементов", server.ErrArchiveLimit, maxExpandedObjects)
+	}
+	for _, entry := range entries {
+		if err := validateEntryPath(entry.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+
+		member, err := archive.Open(entry, nil)
+		if err != nil {
+			return fmt.Errorf("ошибка при открытии файла в архиве: %w", err)
+		}
+		err = ml.putExpanded(ctx, data, entry.Name, member, entry.UncompressedSize)
+		member.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandTar читает архив потоком прямо из тела запроса
+func (ml *MinioLoader) expandTar(ctx context.Context, content io.Reader, kind containerKind, data *server.UploadRequestMetadata) error {
+	archive := &tarArchive{open: func() (io.ReadCloser, error) { return io.NopCloser(content), nil }, kind: kind}
+	stream, err := archive.openStream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	for count := 0; ; count++ {
+		header, err := stream.reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка при чтении tar-архива: %w", err)
+		}
+		if count >= maxExpandedObjects {
+			return fmt.Errorf("%w: в архиве больше %d элементов", server.ErrArchiveLimit, maxExpandedObjects)
+		}
+		if err := validateEntryPath(header.Name); err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			slog.Info("Элемент архива пропущен", "file_name", header.Name, "type", string(header.Typeflag))
+			continue
+		}
+		if err := ml.putExpanded(ctx, data, header.Name, stream.reader, header.Size); err != nil {
+			return err
+		}
+	}
+}
+
+// putExpanded сохраняет элемент архива отдельным объектом и добавляет его
+// в манифест вместе с CRC32 и SHA-256 содержимого
+func (ml *MinioLoader) putExpanded(ctx context.Context, data *server.UploadRequestMetadata, entryPath string, content io.Reader, size int64) error {
+	object := &server.ExpandedObject{
+		Path: strings.TrimPrefix(entryPath, "./"),
+		ID:   uuid.NewString(),
+		Size: size,
+	}
+	object.Key = path.Join(data.Key, object.ID)
+
+	crcHash := crc32.NewIEEE()
+	shaHash := sha256.New()
+	reader := io.TeeReader(content, io.MultiWriter(crcHash, shaHash))
+
+	name := path.Base(object.Path)
+	_, err := ml.client.PutObject(ctx, ml.bucketName, object.Key, reader, size, minio.PutObjectOptions{
+		ContentType: getContentType(name),
+		PartSize:    uploadChunkSize,
+		UserMetadata: map[string]string{
+			uploadedAtKey:   time.Now().Format(time.RFC3339),
+			originalNameKey: name,
+			entryPathKey:    object.Path,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при загрузке элемента %s в MinIO: %w", entryPath, err)
+	}
+
+	object.CRC32 = crcHash.Sum32()
+	object.SHA256 = hex.EncodeToString(shaHash.Sum(nil))
+	data.Manifest = append(data.Manifest, object)
+	return nil
+}
+
+// validateEntryPath отклоняет пути, которые при распаковке в файловую
+// систему вышли бы за пределы каталога назначения (zip slip)
+func validateEntryPath(entryPath string) error {
+	switch {
+	case entryPath == "":
+		return fmt.Errorf("%w: пустой путь", server.ErrUnsafeEntryPath)
+	case strings.ContainsAny(entryPath, "\\\x00"):
+		return fmt.Errorf("%w: %q", server.ErrUnsafeEntryPath, entryPath)
+	case strings.HasPrefix(entryPath, "/"), hasDriveLetter(entryPath):
+		return fmt.Errorf("%w: абсолютный путь %q", server.ErrUnsafeEntryPath, entryPath)
+	}
+
+	for _, segment := range strings.Split(entryPath, "/") {
+		if segment == ".." {
+			return fmt.Errorf("%w: %q выходит за пределы архива", server.ErrUnsafeEntryPath, entryPath)
+		}
+	}
+	return nil
+}
+
+// hasDriveLetter распознает путь Windows с буквой диска: "C:" или "C:/...".
+// Двоеточие на второй позиции в других путях, например "a:b", допустимо.
+func hasDriveLetter(entryPath string) bool {
+	if len(entryPath) < 2 || entryPath[1] != ':' {
+		return false
+	}
+	letter := entryPath[0] | 0x20
+	if letter < 'a' || letter > 'z' {
+		return false
+	}
+	return len(entryPath) == 2 || entryPath[2] == '/'
+}
+
+// removeExpanded удаляет объекты, созданные до ошибки распаковки
+func (ml *MinioLoader) removeExpanded(ctx context.Context, manifest []*server.ExpandedObject) {
+	if len(manifest) == 0 {
+		return
+	}
+
+	objectsCh := make(chan minio.ObjectInfo, len(manifest))
+	for _, object := range manifest {
+		objectsCh <- minio.ObjectInfo{Key: object.Key}
+	}
+	close(objectsCh)
+
+	for result := range ml.client.RemoveObjects(ctx, ml.bucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		slog.Warn("Не удалось удалить объект после ошибки распаковки", "key", result.ObjectName, "error", result.Err)
+	}
+}
@@ -0,0 +1,50 @@
+package minio
+
+import (
+	"errors"
+	"s3_multiclient/server"
+	"testing"
+)
+
+func TestValidateEntryPath(t *testing.T) {
+	tests := []struct {
+		entryPath string
+		unsafe    bool
+	}{
+		{"file.txt", false},
+		{"dir/file.txt", false},
+		{"./dir/file.txt", false},
+		{"dir/", false},
+		{"a:b", false},
+		{"a:b/c.txt", false},
+		{"1:/file.txt", false},
+		{"dir/C:/file.txt", false},
+		{"file..txt", false},
+		{"..file", false},
+
+		{"", true},
+		{"/etc/passwd", true},
+		{"C:", true},
+		{"c:/windows/win.ini", true},
+		{"C:/", true},
+		{"C:\\windows", true},
+		{"dir\\file.txt", true},
+		{"file\x00.txt", true},
+		{"..", true},
+		{"../file.txt", true},
+		{"dir/../../file.txt", true},
+		{"dir/..", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entryPath, func(t *testing.T) {
+			err := validateEntryPath(tt.entryPath)
+			if tt.unsafe && !errors.Is(err, server.ErrUnsafeEntryPath) {
+				t.Fatalf("путь %q должен быть отклонен, получено %v", tt.entryPath, err)
+			}
+			if !tt.unsafe && err != nil {
+				t.Fatalf("путь %q должен быть допустим, получено %v", tt.entryPath, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package minio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"s3_multiclient/file/sniff"
+	"s3_multiclient/server"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	sourceArchiveKey = "X-Source-Archive"
+	sourceCRC32Key   = "X-Source-CRC32"
+)
+
+// ExtractFile копирует элемент архива в новый объект без передачи данных
+// клиенту. Содержимое распаковывается на стороне сервиса и сразу
+// загружается в MinIO. Существующий объект не перезаписывается: проверка
+// до распаковки избавляет от лишней работы, а от параллельной записи того
+// же ключа защищает условная запись с If-None-Match.
+func (ml *MinioLoader) ExtractFile(ctx context.Context, data *server.ExtractRequestMetadata) error {
+	slog.Info("Начало извлечения элемента архива", "object_id", data.Source.ID, "target_id", data.ID)
+
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	info, err := ml.statObject(ctx, data.Source.Key)
+	if err != nil {
+		return err
+	}
+
+	if err := ml.checkAbsent(ctx, data.Key); err != nil {
+		return err
+	}
+
+	member, err := ml.openMember(ctx, info, data.Source.Member, data.Source.Password)
+	if err != nil {
+		return err
+	}
+	defer member.Close()
+
+	entry := member.entry
+	if entry.IsDir {
+		return fmt.Errorf("%w: элемент %s является каталогом", server.ErrObjectNotFound, entry.Name)
+	}
+
+	content, err := member.archive.Open(entry, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии файла в архиве: %w", err)
+	}
+	defer content.Close()
+
+	var body io.Reader = content
+	checksum, ok := member.knownCRC32(data.Source.Member)
+	if !ok {
+		// Метаданные уходят в заголовках раньше тела, поэтому CRC32 нужен
+		// до PutObject. Элемент распаковывается один раз во временный буфер,
+		// CRC32 считается по пути.
+		hash := crc32.NewIEEE()
+		source, size, cleanup, err := ml.spool(io.TeeReader(content, hash), entry.UncompressedSize,
+			ml.archive.NestedMemoryLimit, ml.archive.NestedMaxSize)
+		if err != nil {
+			return fmt.Errorf("не удалось распаковать файл из архива: %w", err)
+		}
+		defer cleanup()
+
+		checksum = hash.Sum32()
+		body = io.NewSectionReader(source, 0, size)
+	}
+
+	buffered := bufio.NewReaderSize(body, sniff.PrefixSize)
+	prefix, err := buffered.Peek(sniff.PrefixSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return fmt.Errorf("ошибка при чтении файла в архиве: %w", err)
+	}
+
+	name := path.Base(entry.Name)
+	contentType := getContentType(name)
+	if contentType == defaultContentType {
+		contentType = http.DetectContentType(prefix)
+	}
+
+	userMetadata := map[string]string{
+		uploadedAtKey:    time.Now().Format(time.RFC3339),
+		originalNameKey:  name,
+		sourceArchiveKey: data.Source.Key,
+		sourceCRC32Key:   strconv.FormatUint(uint64(checksum), 10),
+	}
+	if kind := sniff.DetectContainer(prefix); kind != sniff.ContainerNone {
+		userMetadata[containerKindKey] = kind
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType:  contentType,
+		PartSize:     uploadChunkSize,
+		UserMetadata: userMetadata,
+	}
+	opts.SetMatchETagExcept("*")
+
+	_, err = ml.client.PutObject(ctx, ml.bucketName, data.Key, buffered, entry.UncompressedSize, opts)
+	if isPreconditionFailed(err) {
+		return fmt.Errorf("%w: %s", server.ErrObjectExists, data.Key)
+	}
+	if err != nil {
+		slog.Error("Не удалось сохранить извлеченный файл", "key", data.Key, "error", err)
+		return fmt.Errorf("ошибка при загрузке файла в MinIO: %w", err)
+	}
+
+	data.FileName = name
+	data.ContentType = contentType
+	data.Size = entry.UncompressedSize
+
+	slog.Info("Элемент архива извлечен в отдельный объект", "object_id", data.Source.ID, "file_name", entry.Name, "target_key", data.Key)
+	return nil
+}
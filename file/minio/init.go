@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"s3_multiclient/config"
 	"strings"
+	"sync"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -14,9 +15,16 @@ import (
 type MinioLoader struct {
 	client     *minio.Client
 	bucketName string
+	trash      trashSettings
+	archive    config.ArchiveConfig
+
+	zipDirectories *zipDirectoryCache
+	// resumableLocks — идентификаторы возобновляемых загрузок, в которые
+	// сейчас пишет PATCH
+	resumableLocks sync.Map
 }
 
-func Init(cfg config.MinIOConfig) (*MinioLoader, error) {
+func Init(cfg config.MinIOConfig, archiveCfg config.ArchiveConfig) (*MinioLoader, error) {
 	minioClient, err := minio.New(cfg.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
 		Region: cfg.Location,
@@ -27,10 +35,18 @@ func Init(cfg config.MinIOConfig) (*MinioLoader, error) {
 		return nil, err
 	}
 
-	slog.Info("MinioClient подключен", "endpoint", cfg.Endpoint)
+	slog.Info("MinioClient подключен", "storage", cfg.Storage, "endpoint", cfg.Endpoint, "bucket", cfg.BucketName)
 	return &MinioLoader{
 		client:     minioClient,
 		bucketName: cfg.BucketName,
+		trash: trashSettings{
+			enabled:       cfg.SoftDelete,
+			prefix:        cfg.TrashPrefix,
+			retention:     cfg.TrashRetention,
+			purgeInterval: cfg.TrashPurgeInterval,
+		},
+		archive:        archiveCfg,
+		zipDirectories: newZipDirectoryCache(archiveCfg.DirectoryCacheSize, archiveCfg.DirectoryCacheMaxFiles),
 	}, nil
 }
 
@@ -0,0 +1,139 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"s3_multiclient/load"
+	"s3_multiclient/server"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Сессии multipart-загрузки отображаются на multipart-загрузки S3 один
+// к одному: идентификатор сессии — UploadId, список частей хранит сам S3.
+// Незавершенные сессии удаляет правило AbortIncompleteMultipartUpload.
+
+func (ml *MinioLoader) core() minio.Core {
+	return minio.Core{Client: ml.client}
+}
+
+// CreateMultipartUpload создает сессию с теми же метаданными, что пишет
+// UploadFile. Формат архива до получения данных неизвестен и в метаданные
+// не попадает: при чтении он определяется по сигнатуре.
+func (ml *MinioLoader) CreateMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	uploadID, err := ml.core().NewMultipartUpload(ctx, ml.bucketName, data.Key, minio.PutObjectOptions{
+		ContentType: data.ContentType,
+		UserMetadata: uploadMetadata(&server.UploadRequestMetadata{
+			ID:          data.ID,
+			Key:         data.Key,
+			FileName:    data.FileName,
+			ContentType: data.ContentType,
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при создании multipart-загрузки: %w", err)
+	}
+	data.UploadID = uploadID
+
+	slog.Info("Создана multipart-загрузка", "object_id", data.ID, "key", data.Key, "upload_id", uploadID)
+	return nil
+}
+
+func (ml *MinioLoader) PutMultipartPart(ctx context.Context, progressReader *load.ProgressReader, data *server.MultipartUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	part, err := ml.core().PutObjectPart(ctx, ml.bucketName, data.Key, data.UploadID, data.PartNumber,
+		progressReader, data.PartSize, minio.PutObjectPartOptions{})
+	if err != nil {
+		return multipartError(fmt.Sprintf("ошибка при загрузке части %d", data.PartNumber), data, err)
+	}
+
+	data.Parts = []*server.UploadedPart{{PartNumber: part.PartNumber, ETag: part.ETag, Size: part.Size}}
+	slog.Info("Часть multipart-загрузки принята", "upload_id", data.UploadID, "part_number", part.PartNumber, "size", part.Size)
+	return nil
+}
+
+func (ml *MinioLoader) ListMultipartParts(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	data.Parts = make([]*server.UploadedPart, 0)
+	marker := 0
+	for {
+		result, err := ml.core().ListObjectParts(ctx, ml.bucketName, data.Key, data.UploadID, marker, 0)
+		if err != nil {
+			return multipartError("ошибка при получении списка частей", data, err)
+		}
+
+		for _, part := range result.ObjectParts {
+			data.Parts = append(data.Parts, &server.UploadedPart{
+				PartNumber:   part.PartNumber,
+				ETag:         part.ETag,
+				Size:         part.Size,
+				LastModified: part.LastModified,
+			})
+		}
+
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+func (ml *MinioLoader) CompleteMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	parts := make([]minio.CompletePart, 0, len(data.Parts))
+	for _, part := range data.Parts {
+		parts = append(parts, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	if _, err := ml.core().CompleteMultipartUpload(ctx, ml.bucketName, data.Key, data.UploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return multipartError("ошибка при завершении multipart-загрузки", data, err)
+	}
+
+	info, err := ml.statObject(ctx, data.Key)
+	if err != nil {
+		return err
+	}
+	data.FileName = determineFileName(info)
+	data.ContentType = info.ContentType
+	data.Size = info.Size
+
+	slog.Info("Медиафайл успешно загружен в MinIO", "object_id", data.ID, "key", data.Key, "parts_quantity", len(parts))
+	return nil
+}
+
+func (ml *MinioLoader) AbortMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	if err := ml.core().AbortMultipartUpload(ctx, ml.bucketName, data.Key, data.UploadID); err != nil {
+		return multipartError("ошибка при отмене multipart-загрузки", data, err)
+	}
+
+	slog.Info("Multipart-загрузка отменена", "object_id", data.ID, "upload_id", data.UploadID)
+	return nil
+}
+
+func multipartError(message string, data *server.MultipartUploadMetadata, err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchUpload":
+		return fmt.Errorf("%w: multipart-загрузка %s", server.ErrObjectNotFound, data.UploadID)
+	case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+		return fmt.Errorf("%w: %v", server.ErrInvalidParts, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
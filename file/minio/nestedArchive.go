@@ -0,0 +1,213 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"s3_multiclient/file/sniff"
+	"s3_multiclient/server"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// openedMember — элемент, найденный по цепочке селекторов. archive — самый
+// внутренний архив, его Close закрывает и все внешние уровни.
+type openedMember struct {
+	archive memberArchive
+	entry   *server.ArchiveEntry
+	kind    containerKind
+	indexes []int
+}
+
+func (om *openedMember) etag(archiveETag string) string {
+	return memberETag(archiveETag, om.indexes)
+}
+
+// knownCRC32 возвращает CRC32 элемента, если он известен без чтения
+// содержимого: у ZIP он есть в каталоге, у tar — только если элемент
+// выбирали по нему
+func (om *openedMember) knownCRC32(selector *server.MemberSelector) (uint32, bool) {
+	for selector.Nested != nil {
+		selector = selector.Nested
+	}
+	if om.kind == containerZip || selector.CRC32 != nil {
+		return om.entry.CRC32, true
+	}
+	return 0, false
+}
+
+func (om *openedMember) Close() {
+	om.archive.Close()
+}
+
+// openMember проходит цепочку селекторов от хранимого объекта
+// до элемента самого глубокого архива
+func (ml *MinioLoader) openMember(ctx context.Context, info minio.ObjectInfo, selector *server.MemberSelector, password server.ArchivePassword) (*openedMember, error) {
+	if depth := selector.Depth(); depth > ml.archive.NestedMaxDepth {
+		return nil, fmt.Errorf("%w: глубина вложенности %d больше допустимой %d", server.ErrArchiveLimit, depth, ml.archive.NestedMaxDepth)
+	}
+
+	archive, kind, err := ml.openArchive(ctx, info, password)
+	if err != nil {
+		return nil, err
+	}
+
+	member := &openedMember{archive: archive, kind: kind}
+	for {
+		member.entry, err = member.archive.Select(selector)
+		if err != nil {
+			member.Close()
+			return nil, err
+		}
+		member.indexes = append(member.indexes, member.entry.Index)
+
+		if selector = selector.Nested; selector == nil {
+			return member, nil
+		}
+
+		member.archive, member.kind, err = ml.openNestedArchive(member.archive, member.entry, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// openMemberArchive открывает как архив элемент, на который указывает
+// цепочка селекторов
+func (ml *MinioLoader) openMemberArchive(ctx context.Context, info minio.ObjectInfo, selector *server.MemberSelector, password server.ArchivePassword) (memberArchive, containerKind, error) {
+	if selector.Depth() >= ml.archive.NestedMaxDepth {
+		return nil, containerNone, fmt.Errorf("%w: глубина вложенности больше допустимой %d", server.ErrArchiveLimit, ml.archive.NestedMaxDepth)
+	}
+
+	member, err := ml.openMember(ctx, info, selector, password)
+	if err != nil {
+		return nil, containerNone, err
+	}
+	return ml.openNestedArchive(member.archive, member.entry, password)
+}
+
+// openNestedArchive открывает элемент архива как архив. Несжатый ZIP внутри
+// ZIP читается прямо из внешнего архива, сжатый ZIP выгружается в буфер, так
+// как zip.Reader нужен io.ReaderAt, а tar читается потоком из внешнего архива.
+// При ошибке закрывает parent. Вложенный ZIP открывается с тем же паролем.
+func (ml *MinioLoader) openNestedArchive(parent memberArchive, entry *server.ArchiveEntry, password server.ArchivePassword) (memberArchive, containerKind, error) {
+	if zipParent, ok := parent.(*zipArchive); ok {
+		section, ok, err := zipParent.storedSection(entry)
+		if err != nil {
+			parent.Close()
+			return nil, containerNone, err
+		}
+		if ok && detectNestedContainer(io.NewSectionReader(section, 0, sniff.PrefixSize), entry) == containerZip {
+			archive, err := newZipArchive(section, section.Size(), password, parent.Close)
+			return archive, containerZip, err
+		}
+	}
+
+	content, err := parent.Open(entry, nil)
+	if err != nil {
+		parent.Close()
+		return nil, containerNone, fmt.Errorf("ошибка при открытии вложенного архива %s: %w", entry.Name, err)
+	}
+
+	prefix := make([]byte, sniff.PrefixSize)
+	n, err := io.ReadFull(content, prefix)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		content.Close()
+		parent.Close()
+		return nil, containerNone, fmt.Errorf("ошибка при чтении вложенного архива %s: %w", entry.Name, err)
+	}
+	prefix = prefix[:n]
+
+	kind := detectNestedContainer(bytes.NewReader(prefix), entry)
+	slog.Info("Открывается вложенный архив", "file_name", entry.Name, "kind", kind, "size", entry.UncompressedSize)
+
+	switch kind {
+	case containerZip:
+		source, _, cleanup, err := ml.spool(io.MultiReader(bytes.NewReader(prefix), content), entry.UncompressedSize,
+			ml.archive.NestedMemoryLimit, ml.archive.NestedMaxSize)
+		content.Close()
+		if err != nil {
+			parent.Close()
+			return nil, kind, fmt.Errorf("не удалось подготовить вложенный архив %s: %w", entry.Name, err)
+		}
+		archive, err := newZipArchive(source, entry.UncompressedSize, password, func() { cleanup(); parent.Close() })
+		return archive, kind, err
+	case containerTar, containerTarGz, containerTarZst:
+		content.Close()
+		open := func() (io.ReadCloser, error) { return parent.Open(entry, nil) }
+		return &nestedTarArchive{tarArchive: &tarArchive{open: open, kind: kind}, parent: parent}, kind, nil
+	default:
+		content.Close()
+		parent.Close()
+		return nil, kind, fmt.Errorf("%w: элемент %s", server.ErrNotArchive, entry.Name)
+	}
+}
+
+func detectNestedContainer(prefixReader io.Reader, entry *server.ArchiveEntry) containerKind {
+	prefix, _ := io.ReadAll(io.LimitReader(prefixReader, sniff.PrefixSize))
+	if kind := sniff.DetectContainer(prefix); kind != sniff.ContainerNone {
+		return containerKind(kind)
+	}
+	return containerHint("", entry.Name)
+}
+
+// nestedTarArchive закрывает внешний архив вместе с вложенным tar
+type nestedTarArchive struct {
+	*tarArchive
+	parent memberArchive
+}
+
+func (nt *nestedTarArchive) Close() {
+	nt.parent.Close()
+}
+
+// spool сохраняет содержимое размера size в память, если оно укладывается
+// в memoryLimit, иначе во временный файл не больше maxSize. При size < 0
+// размер заранее неизвестен и содержимое всегда пишется в файл. Возвращает
+// фактический размер и функцию освобождения ресурсов.
+func (ml *MinioLoader) spool(content io.Reader, size, memoryLimit, maxSize int64) (io.ReaderAt, int64, func(), error) {
+	if size > maxSize {
+		return nil, 0, nil, fmt.Errorf("%w: размер %d больше допустимого %d", server.ErrArchiveLimit, size, maxSize)
+	}
+
+	if size >= 0 && size <= memoryLimit {
+		buffer := make([]byte, size)
+		if _, err := io.ReadFull(content, buffer); err != nil {
+			return nil, 0, nil, fmt.Errorf("ошибка чтения в буфер: %w", err)
+		}
+		return bytes.NewReader(buffer), size, func() {}, nil
+	}
+
+	file, err := os.CreateTemp(ml.archive.TempDir, "nested-archive-*")
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	cleanup := func() {
+		file.Close()
+		os.Remove(file.Name())
+	}
+
+	limit := size
+	if size < 0 {
+		limit = maxSize + 1
+	}
+
+	written, err := io.Copy(file, io.LimitReader(content, limit))
+	switch {
+	case err != nil:
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("ошибка записи во временный файл: %w", err)
+	case size < 0 && written > maxSize:
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("%w: размер больше допустимого %d", server.ErrArchiveLimit, maxSize)
+	case size >= 0 && written != size:
+		cleanup()
+		return nil, 0, nil, fmt.Errorf("ошибка записи во временный файл: записано %d байт вместо %d", written, size)
+	}
+
+	return file, written, cleanup, nil
+}
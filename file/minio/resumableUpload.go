@@ -0,0 +1,392 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"s3_multiclient/file/sniff"
+	"s3_multiclient/load"
+	"s3_multiclient/server"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+)
+
+// Состояние возобновляемой загрузки хранится в бакете, а не в памяти
+// процесса, поэтому загрузку можно продолжить после перезапуска сервиса.
+// Состояние завершенной загрузки остается с отметкой Completed, чтобы HEAD
+// после завершения сообщал полную длину. Ни его, ни брошенные загрузки
+// никто не удаляет: для префикса resumablePrefix нужно правило жизненного
+// цикла бакета с удалением объектов и AbortIncompleteMultipartUpload.
+const (
+	resumablePrefix = stagingPrefix + "tus/"
+
+	resumablePartUnit    = 1024 * 1024
+	minResumablePartSize = 5 * resumablePartUnit
+	maxResumablePartSize = 64 * resumablePartUnit
+	maxResumableParts    = 10000
+)
+
+// resumableState — состояние загрузки. Полные части лежат в multipart-загрузке
+// MinIO, остаток меньше части — в отдельном объекте-хвосте, потому что
+// S3 не принимает части меньше 5 МиБ, кроме последней.
+type resumableState struct {
+	UploadID     string `json:"upload_id"`
+	RelativePath string `json:"relative_path"`
+	ID           string `json:"id"`
+	Key          string `json:"key"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Length       int64  `json:"length"`
+	PartSize     int64  `json:"part_size"`
+
+	MultipartID   string               `json:"multipart_id,omitempty"`
+	ContainerKind string               `json:"container_kind,omitempty"`
+	UserMetadata  map[string]string    `json:"user_metadata,omitempty"`
+	Parts         []minio.CompletePart `json:"parts,omitempty"`
+
+	// Committed — байты в отправленных частях, TailSize — байты в хвосте
+	Committed int64     `json:"committed"`
+	TailSize  int64     `json:"tail_size"`
+	Completed bool      `json:"completed,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (rs *resumableState) offset() int64 {
+	return rs.Committed + rs.TailSize
+}
+
+func resumableStateKey(uploadID string) string {
+	return resumablePrefix + uploadID + ".json"
+}
+
+// resumableTailKey включает смещение конца хвоста: новый хвост пишется
+// рядом со старым, и состояние всегда ссылается на целый объект
+func resumableTailKey(uploadID string, offset int64) string {
+	return resumablePrefix + uploadID + ".tail." + strconv.FormatInt(offset, 10)
+}
+
+// resumablePartSize подбирает размер части так, чтобы загрузка длины
+// length уложилась в 10000 частей: length/10000 с округлением вверх
+// до целого МиБ, но не меньше 5 МиБ. Больше 64 МиБ часть не бывает,
+// это гарантирует ограничение server.MaxResumableUploadSize.
+func resumablePartSize(length int64) int64 {
+	partSize := (length + maxResumableParts - 1) / maxResumableParts
+	partSize = (partSize + resumablePartUnit - 1) / resumablePartUnit * resumablePartUnit
+	return min(max(partSize, minResumablePartSize), maxResumablePartSize)
+}
+
+func (ml *MinioLoader) CreateResumableUpload(ctx context.Context, data *server.ResumableUploadMetadata) error {
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	data.UploadID = uuid.NewString()
+	state := &resumableState{
+		UploadID:     data.UploadID,
+		RelativePath: data.RelativePath,
+		ID:           data.ID,
+		Key:          data.Key,
+		FileName:     data.FileName,
+		ContentType:  data.ContentType,
+		Length:       data.Length,
+		PartSize:     resumablePartSize(data.Length),
+		CreatedAt:    time.Now(),
+	}
+
+	// Пустой файл готов сразу, PATCH для него не нужен
+	if state.Length == 0 {
+		if err := ml.finishResumableUpload(ctx, state, nil); err != nil {
+			return err
+		}
+		data.Completed = true
+		return nil
+	}
+
+	if err := ml.saveResumableState(ctx, state); err != nil {
+		return err
+	}
+
+	slog.Info("Создана возобновляемая загрузка", "upload_id", state.UploadID, "key", state.Key, "part_size", state.PartSize)
+	return nil
+}
+
+func (ml *MinioLoader) GetResumableUpload(ctx context.Context, data *server.ResumableUploadMetadata) error {
+	state, err := ml.loadResumableState(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	data.ID = state.ID
+	data.Key = state.Key
+	data.Length = state.Length
+	data.Offset = state.offset()
+	return nil
+}
+
+// WriteResumableUpload дописывает тело PATCH с позиции data.Offset. Данные
+// копятся в буфере размером с часть; полный буфер уходит частью
+// multipart-загрузки, остаток при обрыве соединения сохраняется хвостом,
+// так что принятые байты не теряются.
+func (ml *MinioLoader) WriteResumableUpload(ctx context.Context, progressReader *load.ProgressReader, data *server.ResumableUploadMetadata) error {
+	// Блокировка действует в пределах процесса: параллельные PATCH одной
+	// загрузки через разные экземпляры сервиса не обнаруживаются
+	if _, locked := ml.resumableLocks.LoadOrStore(data.UploadID, struct{}{}); locked {
+		return fmt.Errorf("%w: %s", server.ErrUploadLocked, data.UploadID)
+	}
+	defer ml.resumableLocks.Delete(data.UploadID)
+
+	state, err := ml.loadResumableState(ctx, data)
+	if err != nil {
+		return err
+	}
+	data.ID = state.ID
+	data.Key = state.Key
+	data.Length = state.Length
+
+	if data.Offset != state.offset() {
+		return fmt.Errorf("%w: получено %d, сохранено %d", server.ErrUploadOffsetMismatch, data.Offset, state.offset())
+	}
+	if data.ChunkSize >= 0 && data.Offset+data.ChunkSize > state.Length {
+		return fmt.Errorf("%w: %d байт с позиции %d при длине %d", server.ErrUploadTooLarge, data.ChunkSize, data.Offset, state.Length)
+	}
+	// Повтор PATCH, ответ на который до клиента не дошел
+	if state.Completed {
+		data.Offset = state.Length
+		data.Completed = true
+		return nil
+	}
+
+	buffer := make([]byte, state.PartSize)
+	buffered, err := ml.readResumableTail(ctx, state, buffer)
+	if err != nil {
+		return err
+	}
+	tailKey := resumableTailKey(state.UploadID, state.offset())
+
+	body := io.LimitReader(progressReader, state.Length-state.offset())
+	for {
+		read, readErr := io.ReadFull(body, buffer[buffered:])
+		buffered += read
+
+		if state.Committed+int64(buffered) == state.Length {
+			hadTail := state.TailSize > 0
+			if err := ml.finishResumableUpload(ctx, state, buffer[:buffered]); err != nil {
+				return err
+			}
+			ml.removeStaging(ctx, tailKey, hadTail)
+			data.Offset = state.Length
+			data.Completed = true
+			return nil
+		}
+
+		if buffered == len(buffer) {
+			hadTail := state.TailSize > 0
+			if err := ml.putResumablePart(ctx, state, buffer); err != nil {
+				return err
+			}
+			ml.removeStaging(ctx, tailKey, hadTail)
+			state.TailSize = 0
+			tailKey = resumableTailKey(state.UploadID, state.offset())
+			buffered = 0
+			continue
+		}
+
+		// Буфер не заполнен, значит тело закончилось или оборвалось
+		if int64(buffered) != state.TailSize {
+			if err := ml.saveResumableTail(ctx, state, buffer[:buffered], tailKey); err != nil {
+				return err
+			}
+		}
+		data.Offset = state.offset()
+
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			slog.Warn("Передача части загрузки прервана", "upload_id", state.UploadID, "offset", data.Offset, "error", readErr)
+			return fmt.Errorf("ошибка чтения тела запроса: %w", readErr)
+		}
+
+		slog.Info("Часть возобновляемой загрузки принята", "upload_id", state.UploadID, "offset", data.Offset, "length", state.Length)
+		return nil
+	}
+}
+
+// putResumablePart отправляет полный буфер очередной частью. Перед первой
+// частью по ее началу определяется формат архива и создается
+// multipart-загрузка с теми же метаданными, что пишет UploadFile.
+func (ml *MinioLoader) putResumablePart(ctx context.Context, state *resumableState, part []byte) error {
+	core := ml.core()
+
+	if state.MultipartID == "" {
+		ml.prepareResumableObject(state, part)
+
+		multipartID, err := core.NewMultipartUpload(ctx, ml.bucketName, state.Key, minio.PutObjectOptions{
+			ContentType:  state.ContentType,
+			UserMetadata: state.UserMetadata,
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка при создании multipart-загрузки: %w", err)
+		}
+		state.MultipartID = multipartID
+	}
+
+	partNumber := len(state.Parts) + 1
+	uploaded, err := core.PutObjectPart(ctx, ml.bucketName, state.Key, state.MultipartID, partNumber,
+		bytes.NewReader(part), int64(len(part)), minio.PutObjectPartOptions{})
+	if err != nil {
+		return fmt.Errorf("ошибка при загрузке части %d: %w", partNumber, err)
+	}
+
+	state.Parts = append(state.Parts, minio.CompletePart{PartNumber: partNumber, ETag: uploaded.ETag})
+	state.Committed += int64(len(part))
+	state.TailSize = 0
+	return ml.saveResumableState(ctx, state)
+}
+
+// finishResumableUpload записывает последние байты и превращает загрузку
+// в обычный объект. Если частей не было, объект пишется одним PutObject.
+// Состояние сохраняется с отметкой Completed.
+func (ml *MinioLoader) finishResumableUpload(ctx context.Context, state *resumableState, rest []byte) error {
+	if state.MultipartID == "" {
+		ml.prepareResumableObject(state, rest)
+
+		_, err := ml.client.PutObject(ctx, ml.bucketName, state.Key, bytes.NewReader(rest), int64(len(rest)),
+			minio.PutObjectOptions{ContentType: state.ContentType, UserMetadata: state.UserMetadata})
+		if err != nil {
+			return fmt.Errorf("ошибка при загрузке файла в MinIO: %w", err)
+		}
+	} else {
+		core := ml.core()
+
+		partNumber := len(state.Parts) + 1
+		uploaded, err := core.PutObjectPart(ctx, ml.bucketName, state.Key, state.MultipartID, partNumber,
+			bytes.NewReader(rest), int64(len(rest)), minio.PutObjectPartOptions{})
+		if err != nil {
+			return fmt.Errorf("ошибка при загрузке части %d: %w", partNumber, err)
+		}
+		parts := append(state.Parts, minio.CompletePart{PartNumber: partNumber, ETag: uploaded.ETag})
+
+		if _, err := core.CompleteMultipartUpload(ctx, ml.bucketName, state.Key, state.MultipartID, parts, minio.PutObjectOptions{}); err != nil {
+			return fmt.Errorf("ошибка при завершении multipart-загрузки: %w", err)
+		}
+	}
+
+	slog.Info("Медиафайл успешно загружен в MinIO", "object_id", state.ID, "key", state.Key, "size", state.Length)
+
+	// Объект уже создан, поэтому ошибка записи отметки только логируется
+	state.Completed = true
+	state.Committed = state.Length
+	state.TailSize = 0
+	state.Parts = nil
+	if err := ml.saveResumableState(ctx, state); err != nil {
+		slog.Warn("Не удалось отметить загрузку завершенной", "upload_id", state.UploadID, "error", err)
+	}
+	return nil
+}
+
+// prepareResumableObject определяет формат архива по началу файла
+// и фиксирует метаданные объекта
+func (ml *MinioLoader) prepareResumableObject(state *resumableState, head []byte) {
+	if len(head) > sniff.PrefixSize {
+		head = head[:sniff.PrefixSize]
+	}
+	state.ContainerKind = sniff.DetectContainer(head)
+
+	state.UserMetadata = uploadMetadata(&server.UploadRequestMetadata{
+		ID:            state.ID,
+		Key:           state.Key,
+		FileName:      state.FileName,
+		ContentType:   state.ContentType,
+		Size:          state.Length,
+		ContainerKind: state.ContainerKind,
+	})
+}
+
+func (ml *MinioLoader) saveResumableState(ctx context.Context, state *resumableState) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("ошибка формирования состояния загрузки: %w", err)
+	}
+
+	_, err = ml.client.PutObject(ctx, ml.bucketName, resumableStateKey(state.UploadID), bytes.NewReader(encoded), int64(len(encoded)),
+		minio.PutObjectOptions{ContentType: "application/json"})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния загрузки: %w", err)
+	}
+	return nil
+}
+
+func (ml *MinioLoader) loadResumableState(ctx context.Context, data *server.ResumableUploadMetadata) (*resumableState, error) {
+	object, err := ml.client.GetObject(ctx, ml.bucketName, resumableStateKey(data.UploadID), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения состояния загрузки: %w", err)
+	}
+	defer object.Close()
+
+	state := &resumableState{}
+	if err := json.NewDecoder(object).Decode(state); err != nil {
+		if isObjectNotFound(err) {
+			return nil, fmt.Errorf("%w: загрузка %s", server.ErrObjectNotFound, data.UploadID)
+		}
+		return nil, fmt.Errorf("ошибка чтения состояния загрузки: %w", err)
+	}
+
+	// Загрузка видна только через тот relative_path, в котором создана
+	if state.RelativePath != data.RelativePath {
+		return nil, fmt.Errorf("%w: загрузка %s", server.ErrObjectNotFound, data.UploadID)
+	}
+	return state, nil
+}
+
+// readResumableTail читает сохраненный хвост в начало buffer
+func (ml *MinioLoader) readResumableTail(ctx context.Context, state *resumableState, buffer []byte) (int, error) {
+	if state.TailSize == 0 {
+		return 0, nil
+	}
+
+	object, err := ml.client.GetObject(ctx, ml.bucketName, resumableTailKey(state.UploadID, state.offset()), minio.GetObjectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения хвоста загрузки: %w", err)
+	}
+	defer object.Close()
+
+	if _, err := io.ReadFull(object, buffer[:state.TailSize]); err != nil {
+		return 0, fmt.Errorf("ошибка чтения хвоста загрузки: %w", err)
+	}
+	return int(state.TailSize), nil
+}
+
+// saveResumableTail пишет новый хвост, затем состояние со ссылкой на него,
+// и только после этого удаляет прежний хвост
+func (ml *MinioLoader) saveResumableTail(ctx context.Context, state *resumableState, tail []byte, previousKey string) error {
+	hadTail := state.TailSize > 0
+	state.TailSize = int64(len(tail))
+
+	_, err := ml.client.PutObject(ctx, ml.bucketName, resumableTailKey(state.UploadID, state.offset()), bytes.NewReader(tail), int64(len(tail)),
+		minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения хвоста загрузки: %w", err)
+	}
+	if err := ml.saveResumableState(ctx, state); err != nil {
+		return err
+	}
+
+	ml.removeStaging(ctx, previousKey, hadTail)
+	return nil
+}
+
+// removeStaging удаляет служебный объект, если он есть. Ошибка только
+// логируется: оставшийся объект удалит правило жизненного цикла.
+func (ml *MinioLoader) removeStaging(ctx context.Context, key string, exists bool) {
+	if !exists {
+		return
+	}
+	if err := ml.client.RemoveObject(context.WithoutCancel(ctx), ml.bucketName, key, minio.RemoveObjectOptions{}); err != nil {
+		slog.Warn("Не удалось удалить служебный объект", "key", key, "error", err)
+	}
+}
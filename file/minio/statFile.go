@@ -0,0 +1,50 @@
+package minio
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"s3_multiclient/server"
+)
+
+func (ml *MinioLoader) StatFile(ctx context.Context, data *server.DownloadRequestMetadata) (*server.ObjectMetadata, error) {
+	slog.Info("Начало получения метаданных объекта", "object_id", data.ID)
+
+	info, err := ml.statObject(ctx, data.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &server.ObjectMetadata{
+		ID:           data.ID,
+		Name:         determineFileName(info),
+		Type:         info.ContentType,
+		Size:         info.Size,
+		UploadedAt:   info.UserMetadata[uploadedAtKey],
+		ETag:         server.QuoteETag(info.ETag),
+		LastModified: info.LastModified,
+	}
+
+	if data.Member == nil {
+		return metadata, nil
+	}
+
+	member, err := ml.openMember(ctx, info, data.Member, data.Password)
+	if err != nil {
+		return nil, err
+	}
+	defer member.Close()
+
+	entry := member.entry
+	metadata.Name = path.Base(entry.Name)
+	metadata.MemberPath = entry.Name
+	metadata.Type = getContentType(entry.Name)
+	metadata.Size = entry.UncompressedSize
+	metadata.ETag = member.etag(info.ETag)
+
+	if crc32, ok := member.knownCRC32(data.Member); ok {
+		metadata.CRC32 = &crc32
+	}
+
+	return metadata, nil
+}
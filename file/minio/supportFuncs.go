@@ -1,14 +1,16 @@
 package minio
 
 import (
-	"archive/zip"
 	"fmt"
 	"io"
 	"log/slog"
 	"mime"
+	"net/http"
+	"path"
 	"path/filepath"
 	"s3_multiclient/load"
 	"s3_multiclient/server"
+	"strconv"
 	"strings"
 
 	"github.com/minio/minio-go/v7"
@@ -19,6 +21,7 @@ import (
 type downloadedFileData struct {
 	metadata    *server.DownloadRequestMetadata
 	minioObject minioFileObject
+	part        *server.ContentRange
 }
 
 type minioFileObject struct {
@@ -26,13 +29,29 @@ type minioFileObject struct {
 	info   minio.ObjectInfo
 }
 
+// fileContent описывает отдаваемое клиенту содержимое. Если part задан,
+// reader уже спозиционирован на начало диапазона.
+type fileContent struct {
+	name        string
+	contentType string
+	size        int64
+	part        *server.ContentRange
+	reader      io.Reader
+}
+
 func streamRegularFile(pw *load.ProgressWriter, object *downloadedFileData) error {
 	defer object.minioObject.reader.Close() // как изменить имена полей, чтобы они не путались
 
-	fileName := determineFileName(object.minioObject.info)
+	content := &fileContent{
+		name:        determineFileName(object.minioObject.info),
+		contentType: object.minioObject.info.ContentType,
+		size:        object.minioObject.info.Size,
+		part:        object.part,
+		reader:      object.minioObject.reader,
+	}
 
 	fileManager := FileHandler(streamFileContent)
-	if err := fileManager(pw, fileName, object.minioObject.reader, object.minioObject.info.ContentType); err != nil {
+	if err := fileManager(pw, content); err != nil {
 		return fmt.Errorf("не удалось отправить файл клиенту: %w", err)
 	}
 
@@ -40,92 +59,108 @@ func streamRegularFile(pw *load.ProgressWriter, object *downloadedFileData) erro
 	return nil
 }
 
-func streamFileFromZip(pw *load.ProgressWriter, object *downloadedFileData) error {
-	defer object.minioObject.reader.Close()
-
-	if err := extractFromZip(pw, object); err != nil {
-		return fmt.Errorf("ошибка обработки ZIP-архива: %w", err)
+type FileHandler func(pw *load.ProgressWriter, content *fileContent) error
+
+func streamFileContent(pw *load.ProgressWriter, content *fileContent) error {
+	slog.Info("Начало установки заголовков", "file_name", content.name)
+	pw.Header().Set("Content-Type", content.contentType)
+	pw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, content.name))
+
+	reader := content.reader
+	status := http.StatusOK
+	switch {
+	case content.part != nil:
+		pw.Header().Set("Content-Range", content.part.Header())
+		pw.Header().Set("Content-Length", strconv.FormatInt(content.part.Length, 10))
+		reader = io.LimitReader(reader, content.part.Length)
+		status = http.StatusPartialContent
+	case content.size >= 0:
+		pw.Header().Set("Content-Length", strconv.FormatInt(content.size, 10))
 	}
+	pw.WriteHeader(status)
+	slog.Info("Заголовки установлены", "status", status)
 
-	slog.Info("ZIP-архив обработан и отправлен клиенту", "object_id", object.metadata.ID)
-	return nil
-}
-
-type FileHandler func(pw *load.ProgressWriter, fileName string, content io.Reader, contentType string) error
-
-func streamFileContent(pw *load.ProgressWriter, fileName string, content io.Reader, contentType string) error {
-	slog.Info("Начало установки заголовков", "file_name", fileName)
-	pw.Header().Set("Content-Type", contentType)
-	pw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
-	slog.Info("Заголовки установлены")
-
-	slog.Info("Начало передачи данных клиенту", "file_name", fileName)
-	_, err := io.Copy(pw, content)
+	slog.Info("Начало передачи данных клиенту", "file_name", content.name)
+	_, err := io.Copy(pw, reader)
 	if err != nil {
-		return fmt.Errorf("ошибка при передаче данных: %v", err)
+		return fmt.Errorf("%w: ошибка при передаче данных: %v", server.ErrStreamInterrupted, err)
 	}
-	slog.Info("Данные переданы клиенту", "file_name", fileName)
+	slog.Info("Данные переданы клиенту", "file_name", content.name)
 	return nil
 }
 
-func extractFromZip(pw *load.ProgressWriter, object *downloadedFileData) error {
-	slog.Info("Начало обработки ZIP-архива")
-	zipReader, err := zip.NewReader(object.minioObject.reader, object.minioObject.info.Size)
-	if err != nil {
-		return fmt.Errorf("ошибка при чтении ZIP-архива: %v", err)
+// streamArchiveMember отдает клиенту найденный по цепочке селекторов элемент архива
+func streamArchiveMember(pw *load.ProgressWriter, member *openedMember, data *server.DownloadRequestMetadata, info minio.ObjectInfo) error {
+	entry := member.entry
+	slog.Info("Найден подходящий файл в архиве", "file_name", entry.Name, "selector", data.Member.String())
+
+	etag := member.etag(info.ETag)
+	if err := checkConditions(pw, data, etag, info.LastModified); err != nil {
+		return err
 	}
-	slog.Info("Успешно создан читатель ZIP-архива", "files_quantity", len(zipReader.File))
 
-	searchedFile, err := findFileByCRC32(zipReader, object.metadata.CRC32)
+	part, err := resolveRange(pw, data.RequestedRange(etag, info.LastModified), entry.UncompressedSize)
 	if err != nil {
 		return err
 	}
-	slog.Info("Найден подходящий файл в ZIP-архиве", "file_name", searchedFile.Name, "crc32", object.metadata.CRC32)
 
-	rc, err := searchedFile.Open()
+	rc, err := member.archive.Open(entry, part)
 	if err != nil {
-		return fmt.Errorf("ошибка при открытии файла в ZIP: %v", err)
+		return fmt.Errorf("ошибка при открытии файла в архиве: %w", err)
 	}
 	defer rc.Close()
-	slog.Info("Файл успешно открыт для чтения", "file_name", searchedFile.Name)
+	slog.Info("Файл успешно открыт для чтения", "file_name", entry.Name)
+
+	contentType := getContentType(entry.Name)
+	slog.Info("Определен тип содержимого файла", "file_name", entry.Name, "content_type", contentType)
 
-	contentType := getContentType(searchedFile.Name)
-	slog.Info("Определен тип содержимого файла", "file_name", searchedFile.Name, "content_type", contentType)
+	content := &fileContent{
+		name:        path.Base(entry.Name),
+		contentType: contentType,
+		size:        entry.UncompressedSize,
+		part:        part,
+		reader:      rc,
+	}
 
 	fileManager := FileHandler(streamFileContent)
-	if err := fileManager(pw, searchedFile.Name, rc, contentType); err != nil {
-		return fmt.Errorf("ошибка при обработке файла из ZIP: %v", err)
+	if err := fileManager(pw, content); err != nil {
+		return fmt.Errorf("ошибка при обработке файла из архива: %w", err)
 	}
 
-	slog.Info("Обработка ZIP-архива успешно завершена", "file_name", searchedFile.Name)
+	slog.Info("Файл из архива отправлен клиенту", "object_id", data.ID, "file_name", entry.Name)
 	return nil
 }
 
-func findFileByCRC32(zipReader *zip.Reader, crc32 uint32) (*zip.File, error) {
-	for _, file := range zipReader.File {
-		fmt.Println("crc32 of file", file.CRC32)
-		if file.CRC32 == crc32 {
-			return file, nil
-		}
-	}
-	return nil, fmt.Errorf("file with CRC32 %d not found in ZIP archive", crc32)
-}
+const defaultContentType = "application/octet-stream"
 
 func getContentType(fileName string) string {
 	ext := strings.ToLower(filepath.Ext(fileName))
-	defaultContentType := "application/octet-stream"
 	if contentType := mime.TypeByExtension(ext); contentType != "" {
 		return contentType
 	}
 	return defaultContentType
 }
 
+func isObjectNotFound(err error) bool {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchVersion":
+		return true
+	}
+	return false
+}
+
+// isPreconditionFailed сообщает, что условная запись отклонена:
+// объект с таким ключом уже есть
+func isPreconditionFailed(err error) bool {
+	return minio.ToErrorResponse(err).Code == "PreconditionFailed"
+}
+
 func determineFileName(stat minio.ObjectInfo) string {
 	originalName := stat.UserMetadata[originalNameKey]
 	if originalName != "" {
 		return originalName
 	}
-	return stat.Key
+	return path.Base(stat.Key)
 }
 
 // func generateFileName(contentType string) string {
@@ -0,0 +1,165 @@
+package minio
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"s3_multiclient/server"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-go/v7"
+)
+
+// tarArchive читает tar, tar.gz и tar.zst. Произвольного доступа к элементам
+// у tar нет, поэтому каждый проход заново читает объект с начала: один — чтобы
+// найти элемент, второй — чтобы отдать его. CRC32 считается только тогда,
+// когда он нужен для выбора или списка, так как для этого приходится читать
+// содержимое всех элементов.
+type tarArchive struct {
+	open func() (io.ReadCloser, error)
+	kind containerKind
+}
+
+func (ml *MinioLoader) openTar(ctx context.Context, info minio.ObjectInfo, kind containerKind) *tarArchive {
+	open := func() (io.ReadCloser, error) {
+		minioObject, err := ml.getObject(ctx, info, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return minioObject.reader, nil
+	}
+	return &tarArchive{open: open, kind: kind}
+}
+
+// tarStream — открытый последовательный проход по архиву
+type tarStream struct {
+	reader  *tar.Reader
+	closers []func() error
+}
+
+func (ts *tarStream) Close() error {
+	var errs []error
+	for i := len(ts.closers) - 1; i >= 0; i-- {
+		errs = append(errs, ts.closers[i]())
+	}
+	return errors.Join(errs...)
+}
+
+func (ta *tarArchive) openStream() (*tarStream, error) {
+	raw, err := ta.open()
+	if err != nil {
+		return nil, err
+	}
+	stream := &tarStream{closers: []func() error{raw.Close}}
+
+	var source io.Reader = raw
+	switch ta.kind {
+	case containerTarGz:
+		gzipReader, err := gzip.NewReader(source)
+		if err != nil {
+			stream.Close()
+			return nil, fmt.Errorf("ошибка при чтении gzip-потока: %w", err)
+		}
+		stream.closers = append(stream.closers, gzipReader.Close)
+		source = gzipReader
+	case containerTarZst:
+		zstdReader, err := zstd.NewReader(source, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			stream.Close()
+			return nil, fmt.Errorf("ошибка при чтении zstd-потока: %w", err)
+		}
+		stream.closers = append(stream.closers, func() error { zstdReader.Close(); return nil })
+		source = zstdReader
+	}
+
+	stream.reader = tar.NewReader(source)
+	return stream, nil
+}
+
+// scan проходит архив целиком и собирает каталог
+func (ta *tarArchive) scan(withCRC bool) ([]*server.ArchiveEntry, error) {
+	stream, err := ta.openStream()
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var entries []*server.ArchiveEntry
+	for index := 0; ; index++ {
+		header, err := stream.reader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при чтении tar-архива: %w", err)
+		}
+
+		entry := tarEntry(index, header)
+		if withCRC && header.Typeflag == tar.TypeReg {
+			hash := crc32.NewIEEE()
+			if _, err := io.Copy(hash, stream.reader); err != nil {
+				return nil, fmt.Errorf("ошибка при чтении элемента %s: %w", header.Name, err)
+			}
+			entry.CRC32 = hash.Sum32()
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (ta *tarArchive) Entries() ([]*server.ArchiveEntry, error) {
+	return ta.scan(true)
+}
+
+func (ta *tarArchive) Select(selector *server.MemberSelector) (*server.ArchiveEntry, error) {
+	entries, err := ta.scan(selector.CRC32 != nil)
+	if err != nil {
+		return nil, err
+	}
+	return selector.Select(entries)
+}
+
+func (ta *tarArchive) Open(entry *server.ArchiveEntry, part *server.ContentRange) (io.ReadCloser, error) {
+	stream, err := ta.openStream()
+	if err != nil {
+		return nil, err
+	}
+
+	for index := 0; index <= entry.Index; index++ {
+		if _, err := stream.reader.Next(); err != nil {
+			stream.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: элемент %s пропал из архива", server.ErrObjectNotFound, entry.Name)
+			}
+			return nil, fmt.Errorf("ошибка при чтении tar-архива: %w", err)
+		}
+	}
+
+	member := &tarMemberReader{Reader: stream.reader, stream: stream}
+	return skipToRange(member, part)
+}
+
+func (ta *tarArchive) Close() {}
+
+type tarMemberReader struct {
+	io.Reader
+	stream *tarStream
+}
+
+func (tm *tarMemberReader) Close() error {
+	return tm.stream.Close()
+}
+
+func tarEntry(index int, header *tar.Header) *server.ArchiveEntry {
+	return &server.ArchiveEntry{
+		Index:            index,
+		Name:             header.Name,
+		CompressedSize:   header.Size,
+		UncompressedSize: header.Size,
+		Modified:         header.ModTime,
+		IsDir:            header.Typeflag == tar.TypeDir,
+	}
+}
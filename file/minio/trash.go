@@ -0,0 +1,303 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"s3_multiclient/server"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const (
+	deletedAtKey   = "X-Deleted-At"
+	originalKeyKey = "X-Original-Key"
+
+	// trashTimeLayout дает ключам копий одного объекта лексикографический
+	// порядок, совпадающий с порядком удаления
+	trashTimeLayout = "20060102T150405.000000000Z"
+	trashSeparator  = "@"
+
+	// maxCopyObjectSize — предел одиночного CopyObject в S3. Объекты
+	// больше копируются частями по copyPartSize.
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	copyPartSize      = 1024 * 1024 * 1024
+)
+
+type trashSettings struct {
+	enabled       bool
+	prefix        string
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+// checkKey не дает обращаться к служебным областям бакета через обычные маршруты
+func (ml *MinioLoader) checkKey(key string) error {
+	if strings.HasPrefix(key, stagingPrefix) || ml.trash.enabled && strings.HasPrefix(key, ml.trash.prefix) {
+		return fmt.Errorf("%w: %s", server.ErrReservedKey, key)
+	}
+	return nil
+}
+
+// trashKey — ключ копии в корзине. Время удаления в ключе не дает
+// повторному удалению того же ключа затереть предыдущую копию.
+func (ml *MinioLoader) trashKey(key string, deletedAt time.Time) string {
+	return ml.trashKeyPrefix(key) + deletedAt.UTC().Format(trashTimeLayout)
+}
+
+func (ml *MinioLoader) trashKeyPrefix(key string) string {
+	return ml.trash.prefix + key + trashSeparator
+}
+
+// latestTrashCopy находит последнюю по времени удаления копию ключа
+// в корзине. Под тем же префиксом могут лежать копии ключей, которые
+// начинаются с key@, поэтому остаток ключа должен быть временем удаления.
+func (ml *MinioLoader) latestTrashCopy(ctx context.Context, key string) (string, error) {
+	prefix := ml.trashKeyPrefix(key)
+
+	latest := ""
+	for object := range ml.client.ListObjects(ctx, ml.bucketName, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return "", fmt.Errorf("ошибка листинга корзины: %w", object.Err)
+		}
+		if _, err := time.Parse(trashTimeLayout, strings.TrimPrefix(object.Key, prefix)); err != nil {
+			continue
+		}
+		if object.Key > latest {
+			latest = object.Key
+		}
+	}
+
+	if latest == "" {
+		return "", fmt.Errorf("%w: %s нет в корзине", server.ErrObjectNotFound, key)
+	}
+	return latest, nil
+}
+
+// moveToTrash копирует объект в корзину, дописывая в метаданные время
+// удаления и исходный ключ, после чего удаляет оригинал
+func (ml *MinioLoader) moveToTrash(ctx context.Context, stat minio.ObjectInfo) error {
+	deletedAt := time.Now()
+	trashKey := ml.trashKey(stat.Key, deletedAt)
+
+	userMetadata := maps.Clone(stat.UserMetadata)
+	if userMetadata == nil {
+		userMetadata = make(map[string]string, 2)
+	}
+	userMetadata[deletedAtKey] = deletedAt.Format(time.RFC3339)
+	userMetadata[originalKeyKey] = stat.Key
+
+	if err := ml.copyObject(ctx, stat, trashKey, userMetadata, copyCondition{}); err != nil {
+		slog.Error("Не удалось перенести объект в корзину", "key", stat.Key, "error", err)
+		return fmt.Errorf("не удалось перенести объект в корзину: %w", err)
+	}
+
+	if err := ml.client.RemoveObject(ctx, ml.bucketName, stat.Key, minio.RemoveObjectOptions{VersionID: stat.VersionID}); err != nil {
+		slog.Error("Ошибка удаления объекта из MinIO", "key", stat.Key, "error", err)
+		return fmt.Errorf("ошибка при удалении объекта из MinIO: %w", err)
+	}
+
+	slog.Info("Объект перенесен в корзину", "key", stat.Key, "trash_key", trashKey)
+	return nil
+}
+
+// copyCondition — условие на объект назначения, которое хранилище
+// проверяет атомарно с записью. Нулевое значение — копирование без условий.
+type copyCondition struct {
+	// matchETag — назначение должно существовать с этим ETag (If-Match)
+	matchETag string
+	// absent — назначения не должно быть (If-None-Match: *)
+	absent bool
+}
+
+func (cc copyCondition) setHeader(headers map[string]string) {
+	switch {
+	case cc.matchETag != "":
+		headers["If-Match"] = `"` + cc.matchETag + `"`
+	case cc.absent:
+		headers["If-None-Match"] = "*"
+	}
+}
+
+func (cc copyCondition) apply(opts *minio.PutObjectOptions) {
+	switch {
+	case cc.matchETag != "":
+		opts.SetMatchETag(cc.matchETag)
+	case cc.absent:
+		opts.SetMatchETagExcept("*")
+	}
+}
+
+// copyObject копирует объект на стороне сервера с новыми метаданными.
+// Объекты больше maxCopyObjectSize копируются частями. Если условие
+// condition не выполнено, возвращается ошибка с кодом PreconditionFailed.
+func (ml *MinioLoader) copyObject(ctx context.Context, src minio.ObjectInfo, dstKey string, userMetadata map[string]string, condition copyCondition) error {
+	if src.Size > maxCopyObjectSize {
+		return ml.copyObjectMultipart(ctx, src, dstKey, userMetadata, condition)
+	}
+
+	// CopyObject из minio-go не передает условия на назначение, поэтому
+	// заголовки запроса собираются здесь
+	headers := map[string]string{
+		"x-amz-metadata-directive": "REPLACE",
+		"Content-Type":             src.ContentType,
+	}
+	for name, value := range userMetadata {
+		headers["x-amz-meta-"+name] = value
+	}
+	condition.setHeader(headers)
+
+	_, err := ml.core().CopyObject(ctx, ml.bucketName, src.Key, ml.bucketName, dstKey, headers,
+		minio.CopySrcOptions{VersionID: src.VersionID}, minio.PutObjectOptions{})
+	return err
+}
+
+// copyObjectMultipart копирует объект через UploadPartCopy. ComposeObject
+// из minio-go здесь не подходит: при копировании частями он теряет
+// Content-Type. Источник проверяется по ETag, чтобы части не были взяты
+// из разных версий объекта.
+func (ml *MinioLoader) copyObjectMultipart(ctx context.Context, src minio.ObjectInfo, dstKey string, userMetadata map[string]string, condition copyCondition) error {
+	core := ml.core()
+
+	uploadID, err := core.NewMultipartUpload(ctx, ml.bucketName, dstKey, minio.PutObjectOptions{
+		ContentType:  src.ContentType,
+		UserMetadata: userMetadata,
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при создании multipart-копирования: %w", err)
+	}
+
+	sourceConditions := map[string]string{"x-amz-copy-source-if-match": src.ETag}
+	parts := make([]minio.CompletePart, 0, src.Size/copyPartSize+1)
+	for offset := int64(0); offset < src.Size; offset += copyPartSize {
+		length := min(int64(copyPartSize), src.Size-offset)
+		part, err := core.CopyObjectPart(ctx, ml.bucketName, src.Key, ml.bucketName, dstKey, uploadID,
+			len(parts)+1, offset, length, sourceConditions)
+		if err != nil {
+			ml.abortCopy(ctx, dstKey, uploadID)
+			return fmt.Errorf("ошибка при копировании части %d: %w", len(parts)+1, err)
+		}
+		parts = append(parts, part)
+	}
+
+	opts := minio.PutObjectOptions{}
+	condition.apply(&opts)
+	if _, err := core.CompleteMultipartUpload(ctx, ml.bucketName, dstKey, uploadID, parts, opts); err != nil {
+		ml.abortCopy(ctx, dstKey, uploadID)
+		return fmt.Errorf("ошибка при завершении multipart-копирования: %w", err)
+	}
+	return nil
+}
+
+func (ml *MinioLoader) abortCopy(ctx context.Context, key, uploadID string) {
+	if err := ml.core().AbortMultipartUpload(context.WithoutCancel(ctx), ml.bucketName, key, uploadID); err != nil {
+		slog.Warn("Не удалось отменить multipart-копирование", "key", key, "error", err)
+	}
+}
+
+func (ml *MinioLoader) RestoreFile(ctx context.Context, data *server.RestoreRequestMetadata) error {
+	slog.Info("Начало восстановления объекта из корзины", "object_id", data.ID, "key", data.Key)
+
+	if !ml.trash.enabled {
+		return fmt.Errorf("%w: мягкое удаление отключено для хранилища", server.ErrObjectNotFound)
+	}
+	if err := ml.checkKey(data.Key); err != nil {
+		return err
+	}
+
+	trashKey, err := ml.latestTrashCopy(ctx, data.Key)
+	if err != nil {
+		return err
+	}
+	stat, err := ml.client.StatObject(ctx, ml.bucketName, trashKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return fmt.Errorf("%w: %s нет в корзине", server.ErrObjectNotFound, data.Key)
+		}
+		return fmt.Errorf("не удалось получить метаданные объекта в корзине: %w", err)
+	}
+
+	if err := ml.checkAbsent(ctx, data.Key); err != nil {
+		return err
+	}
+
+	userMetadata := maps.Clone(stat.UserMetadata)
+	delete(userMetadata, deletedAtKey)
+	delete(userMetadata, originalKeyKey)
+
+	// Проверка выше лишь быстро отсекает очевидный конфликт: объект может
+	// появиться между ней и копированием, поэтому копия пишется с
+	// If-None-Match: *.
+	err = ml.copyObject(ctx, stat, data.Key, userMetadata, copyCondition{absent: true})
+	if isPreconditionFailed(err) {
+		return fmt.Errorf("%w: %s", server.ErrObjectExists, data.Key)
+	}
+	if err != nil {
+		slog.Error("Не удалось восстановить объект из корзины", "key", data.Key, "error", err)
+		return fmt.Errorf("не удалось восстановить объект из корзины: %w", err)
+	}
+
+	if err := ml.client.RemoveObject(ctx, ml.bucketName, trashKey, minio.RemoveObjectOptions{}); err != nil {
+		slog.Warn("Объект восстановлен, но копия в корзине не удалена", "trash_key", trashKey, "error", err)
+	}
+
+	data.FileName = determineFileName(stat)
+	data.ContentType = stat.ContentType
+	data.Size = stat.Size
+
+	slog.Info("Объект восстановлен из корзины", "object_id", data.ID, "key", data.Key)
+	return nil
+}
+
+// RunTrashPurge периодически удаляет из корзины объекты старше срока хранения.
+// Время удаления совпадает с LastModified копии в корзине, поэтому для отбора
+// хватает листинга без чтения метаданных каждого объекта.
+func (ml *MinioLoader) RunTrashPurge(ctx context.Context) {
+	if !ml.trash.enabled {
+		return
+	}
+
+	slog.Info("Запуск очистки корзины", "bucket", ml.bucketName, "retention", ml.trash.retention, "interval", ml.trash.purgeInterval)
+	ticker := time.NewTicker(ml.trash.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		ml.purgeTrash(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ml *MinioLoader) purgeTrash(ctx context.Context) {
+	expiredBefore := time.Now().Add(-ml.trash.retention)
+
+	objectsCh := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objectsCh)
+		listed := ml.client.ListObjects(ctx, ml.bucketName, minio.ListObjectsOptions{Prefix: ml.trash.prefix, Recursive: true})
+		for object := range listed {
+			if object.Err != nil {
+				slog.Error("Ошибка листинга корзины", "error", object.Err)
+				return
+			}
+			if object.LastModified.Before(expiredBefore) {
+				objectsCh <- minio.ObjectInfo{Key: object.Key}
+			}
+		}
+	}()
+
+	failed := 0
+	for removeErr := range ml.client.RemoveObjects(ctx, ml.bucketName, objectsCh, minio.RemoveObjectsOptions{}) {
+		slog.Error("Не удалось удалить объект из корзины", "key", removeErr.ObjectName, "error", removeErr.Err)
+		failed++
+	}
+
+	slog.Info("Очистка корзины завершена", "bucket", ml.bucketName, "failed", failed)
+}
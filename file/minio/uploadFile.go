@@ -12,35 +12,93 @@ import (
 )
 
 const (
-	uploadChunkSize = 5 * 1024 * 1024
-	uploadedAtKey   = "X-Uploaded-At"
-	originalNameKey = "X-Original-Name"
+	uploadChunkSize  = 5 * 1024 * 1024
+	uploadedAtKey    = "X-Uploaded-At"
+	originalNameKey  = "X-Original-Name"
+	containerKindKey = "X-Container-Kind"
+
+	// streamPartSize — размер части для тела неизвестной длины. minio-go
+	// читает такое тело частями через один буфер этого размера, 10000 частей
+	// ограничивают объект примерно 156 ГиБ
+	streamPartSize = 16 * 1024 * 1024
 )
 
 func (ml *MinioLoader) UploadFile(ctx context.Context, progressReader *load.ProgressReader, objectData *server.UploadRequestMetadata) error {
-	_, err := ml.client.PutObject(
-		ctx,
-		ml.bucketName,
-		objectData.ID,
-		progressReader,
-		objectData.Size,
-		minio.PutObjectOptions{
-			ContentType: objectData.ContentType,
-			PartSize:    uploadChunkSize,
-			UserMetadata: map[string]string{
-				uploadedAtKey:   time.Now().Format(time.RFC3339),
-				originalNameKey: objectData.FileName,
-			},
-		},
-	)
+	if err := ml.checkKey(objectData.Key); err != nil {
+		return err
+	}
+
+	if objectData.Expand {
+		if err := ml.expandArchive(ctx, progressReader, objectData); err != nil {
+			return err
+		}
+		objectData.Size = progressReader.TotalBytes
+		return nil
+	}
+
+	partSize := uint64(uploadChunkSize)
+	if objectData.Size < 0 {
+		partSize = streamPartSize
+		slog.Info("Длина тела неизвестна, загрузка частями", "object_id", objectData.ID, "part_size", partSize)
+	}
+
+	opts := minio.PutObjectOptions{
+		ContentType:  objectData.ContentType,
+		PartSize:     partSize,
+		UserMetadata: uploadMetadata(objectData),
+	}
+	if objectData.CreateOnly {
+		if err := ml.checkAbsent(ctx, objectData.Key); err != nil {
+			return err
+		}
+		// Проверка выше не защищает от параллельной записи, If-None-Match
+		// закрывает это окно на хранилищах, которые его поддерживают
+		opts.SetMatchETagExcept("*")
+	}
+
+	_, err := ml.client.PutObject(ctx, ml.bucketName, objectData.Key, progressReader, objectData.Size, opts)
+	if isPreconditionFailed(err) {
+		return fmt.Errorf("%w: %s", server.ErrObjectExists, objectData.Key)
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при загрузке файла в MinIO: %v", err)
 	}
 
-	slog.Info("Медиафайл успешно загружен в MinIO", "object_id", objectData.ID)
+	// Для тела неизвестной длины Size был -1, в ответ уходит размер
+	// фактически полученных данных
+	objectData.Size = progressReader.TotalBytes
+
+	slog.Info("Медиафайл успешно загружен в MinIO", "object_id", objectData.ID, "key", objectData.Key, "size", objectData.Size)
+	return nil
+}
+
+// checkAbsent возвращает ErrObjectExists, если объект с ключом key уже есть
+func (ml *MinioLoader) checkAbsent(ctx context.Context, key string) error {
+	_, err := ml.client.StatObject(ctx, ml.bucketName, key, minio.StatObjectOptions{})
+	if err == nil {
+		return fmt.Errorf("%w: %s", server.ErrObjectExists, key)
+	}
+	if !isObjectNotFound(err) {
+		return fmt.Errorf("не удалось получить метаданные объекта: %w", err)
+	}
 	return nil
 }
 
+// uploadMetadata — пользовательские метаданные загружаемого объекта:
+// поля формы и служебные ключи, которые поля формы не перекрывают
+func uploadMetadata(objectData *server.UploadRequestMetadata) map[string]string {
+	userMetadata := make(map[string]string, len(objectData.Metadata)+3)
+	for name, value := range objectData.Metadata {
+		userMetadata[name] = value
+	}
+	userMetadata[uploadedAtKey] = time.Now().Format(time.RFC3339)
+	userMetadata[originalNameKey] = objectData.FileName
+	if objectData.ContainerKind != "" {
+		userMetadata[containerKindKey] = objectData.ContainerKind
+	}
+	return userMetadata
+}
+
 // Сообщения о статусе загрузки
 
 // type FileDownloadResult struct {
@@ -57,4 +115,4 @@ func (ml *MinioLoader) UploadFile(ctx context.Context, progressReader *load.Prog
 //     InProgress
 //     Completed
 //     Failed
-// )
\ No newline at end of file
+// )
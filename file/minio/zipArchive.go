@@ -0,0 +1,257 @@
+package minio
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"s3_multiclient/server"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// zipArchive читает элементы ZIP через io.ReaderAt. Для хранимого объекта
+// источником служит minio.Object, который отдает данные по запросу
+// диапазонами, для вложенного архива — буфер в памяти, временный файл
+// или участок внешнего архива.
+type zipArchive struct {
+	dir      *zipDirectory
+	source   io.ReaderAt
+	password server.ArchivePassword
+	closer   func()
+}
+
+// openZip берет каталог архива из кеша, а при промахе читает его
+// из объекта и кладет в кеш
+func (ml *MinioLoader) openZip(ctx context.Context, info minio.ObjectInfo, password server.ArchivePassword) (*zipArchive, error) {
+	minioObject, err := ml.getObject(ctx, info, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	closer := func() { minioObject.reader.Close() }
+
+	dir, ok := ml.zipDirectories.get(info.Key, info.ETag)
+	if ok {
+		slog.Info("Каталог ZIP-архива взят из кеша", "key", info.Key, "files_quantity", len(dir.entries))
+	} else {
+		dir, err = readZipDirectory(minioObject.reader, info.Size)
+		if err != nil {
+			closer()
+			return nil, err
+		}
+		ml.zipDirectories.put(info.Key, info.ETag, dir)
+	}
+
+	return &zipArchive{dir: dir, source: minioObject.reader, password: password, closer: closer}, nil
+}
+
+// newZipArchive читает каталог архива; при ошибке вызывает closer сам.
+// password нужен только для зашифрованных элементов.
+func newZipArchive(source io.ReaderAt, size int64, password server.ArchivePassword, closer func()) (*zipArchive, error) {
+	dir, err := readZipDirectory(source, size)
+	if err != nil {
+		closer()
+		return nil, err
+	}
+
+	return &zipArchive{dir: dir, source: source, password: password, closer: closer}, nil
+}
+
+func (za *zipArchive) Entries() ([]*server.ArchiveEntry, error) {
+	return append([]*server.ArchiveEntry(nil), za.dir.entries...), nil
+}
+
+func (za *zipArchive) Select(selector *server.MemberSelector) (*server.ArchiveEntry, error) {
+	return selector.Select(za.dir.candidates(selector))
+}
+
+// Open открывает элемент с позиции part.Start. Несжатые элементы читаются
+// напрямую по смещению, сжатые и зашифрованные распаковываются с начала,
+// а байты до начала диапазона отбрасываются.
+func (za *zipArchive) Open(entry *server.ArchiveEntry, part *server.ContentRange) (io.ReadCloser, error) {
+	file := za.dir.reader.File[entry.Index]
+
+	if part != nil {
+		section, ok, err := za.storedSection(entry)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return io.NopCloser(io.NewSectionReader(section, part.Start, part.Length)), nil
+		}
+	}
+
+	raw, err := za.rawSection(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	var rc io.ReadCloser
+	if file.Flags&zipFlagEncrypted != 0 {
+		rc, err = za.openEncrypted(file, raw)
+	} else {
+		rc, err = decompressZipMember(file.Name, file.Method, raw)
+		if err == nil {
+			rc = newCheckedReader(file, rc, true, nil)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return skipToRange(rc, part)
+}
+
+// rawSection возвращает сжатое, возможно зашифрованное, содержимое элемента
+func (za *zipArchive) rawSection(entry *server.ArchiveEntry) (*io.SectionReader, error) {
+	dataOffset, err := za.dir.dataOffset(entry.Index, za.source)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении заголовка элемента %s: %w", entry.Name, err)
+	}
+
+	file := za.dir.reader.File[entry.Index]
+	return io.NewSectionReader(za.source, dataOffset, int64(file.CompressedSize64)), nil
+}
+
+// storedSection возвращает содержимое несжатого незашифрованного элемента
+// как участок архива, без распаковки и копирования
+func (za *zipArchive) storedSection(entry *server.ArchiveEntry) (*io.SectionReader, bool, error) {
+	file := za.dir.reader.File[entry.Index]
+	if file.Method != zip.Store || file.Flags&zipFlagEncrypted != 0 {
+		return nil, false, nil
+	}
+
+	section, err := za.rawSection(entry)
+	if err != nil {
+		return nil, false, err
+	}
+	return section, true, nil
+}
+
+// copyRaw переносит элемент в zw без распаковки, как zip.Writer.Copy.
+// Copy читает данные через ReaderAt каталога, а каталог из кеша не привязан
+// к источнику, поэтому сжатые данные берутся из rawSection.
+func (za *zipArchive) copyRaw(zw *zip.Writer, entry *server.ArchiveEntry) error {
+	raw, err := za.rawSection(entry)
+	if err != nil {
+		return err
+	}
+
+	header := za.dir.reader.File[entry.Index].FileHeader
+	writer, err := zw.CreateRaw(&header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, raw)
+	return err
+}
+
+func (za *zipArchive) Close() {
+	za.closer()
+}
+
+// zipDirectory — разобранный центральный каталог ZIP с индексами по имени
+// и CRC32. Каталог не привязан к источнику данных, поэтому один экземпляр
+// из кеша используют параллельные запросы, каждый со своим источником.
+type zipDirectory struct {
+	reader  *zip.Reader
+	entries []*server.ArchiveEntry
+	byName  map[string][]*server.ArchiveEntry
+	byCRC32 map[uint32][]*server.ArchiveEntry
+
+	// mu защищает source и dataOffsets: смещение данных элемента
+	// zip.File вычисляет по локальному заголовку через свой ReaderAt
+	mu          sync.Mutex
+	source      *directorySource
+	dataOffsets map[int]int64
+}
+
+// directorySource — ReaderAt каталога, за которым на время чтения
+// подставляется источник данных текущего запроса
+type directorySource struct {
+	source io.ReaderAt
+}
+
+func (ds *directorySource) ReadAt(p []byte, off int64) (int, error) {
+	if ds.source == nil {
+		return 0, errors.New("источник данных ZIP-архива не задан")
+	}
+	return ds.source.ReadAt(p, off)
+}
+
+func readZipDirectory(source io.ReaderAt, size int64) (*zipDirectory, error) {
+	dirSource := &directorySource{source: source}
+	zipReader, err := zip.NewReader(dirSource, size)
+	dirSource.source = nil
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ZIP-архива: %w", err)
+	}
+
+	dir := &zipDirectory{
+		reader:      zipReader,
+		entries:     make([]*server.ArchiveEntry, 0, len(zipReader.File)),
+		byName:      make(map[string][]*server.ArchiveEntry),
+		byCRC32:     make(map[uint32][]*server.ArchiveEntry),
+		source:      dirSource,
+		dataOffsets: make(map[int]int64),
+	}
+	for i, file := range zipReader.File {
+		entry := zipEntry(i, file)
+		dir.entries = append(dir.entries, entry)
+		dir.byName[entry.Name] = append(dir.byName[entry.Name], entry)
+		if !entry.IsDir {
+			dir.byCRC32[entry.CRC32] = append(dir.byCRC32[entry.CRC32], entry)
+		}
+	}
+	return dir, nil
+}
+
+// candidates сужает поиск по индексу имени или CRC32. Результат
+// MemberSelector.Select на них тот же, что и на полном каталоге.
+func (zd *zipDirectory) candidates(selector *server.MemberSelector) []*server.ArchiveEntry {
+	switch {
+	case selector.Index != nil:
+		return zd.entries
+	case selector.Path != "":
+		return zd.byName[selector.Path]
+	case selector.CRC32 != nil:
+		return zd.byCRC32[*selector.CRC32]
+	default:
+		return zd.entries
+	}
+}
+
+func (zd *zipDirectory) dataOffset(index int, source io.ReaderAt) (int64, error) {
+	zd.mu.Lock()
+	defer zd.mu.Unlock()
+
+	if dataOffset, ok := zd.dataOffsets[index]; ok {
+		return dataOffset, nil
+	}
+
+	zd.source.source = source
+	defer func() { zd.source.source = nil }()
+
+	dataOffset, err := zd.reader.File[index].DataOffset()
+	if err != nil {
+		return 0, err
+	}
+	zd.dataOffsets[index] = dataOffset
+	return dataOffset, nil
+}
+
+func zipEntry(index int, file *zip.File) *server.ArchiveEntry {
+	return &server.ArchiveEntry{
+		Index:            index,
+		Name:             file.Name,
+		CRC32:            file.CRC32,
+		CompressedSize:   int64(file.CompressedSize64),
+		UncompressedSize: int64(file.UncompressedSize64),
+		Modified:         file.Modified,
+		IsDir:            file.FileInfo().IsDir() || strings.HasSuffix(file.Name, "/"),
+		Encrypted:        file.Flags&zipFlagEncrypted != 0,
+	}
+}
@@ -0,0 +1,358 @@
+package minio
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"s3_multiclient/server"
+)
+
+const (
+	zipFlagEncrypted        = 0x1
+	zipFlagDataDescriptor   = 0x8
+	zipFlagStrongEncryption = 0x40
+
+	zipCryptoHeaderLen = 12
+
+	// WinZip AES: метод 99 и дополнительное поле 0x9901 с параметрами
+	zipMethodAES        = 99
+	aesExtraID          = 0x9901
+	aesIterations       = 1000
+	aesVerifierLen      = 2
+	aesAuthCodeLen      = 10
+	aesVendorVersionAE1 = 1
+)
+
+// errAuthCode — код аутентификации WinZip AES не совпал. Пароль к этому
+// моменту уже подтвержден верификатором, так что это повреждение данных.
+var errAuthCode = errors.New("код аутентификации WinZip AES не совпал")
+
+// openEncrypted расшифровывает и распаковывает элемент, зашифрованный
+// ZipCrypto или WinZip AES. Пароль проверяется до отправки заголовков:
+// по контрольному байту или верификатору, затем по первым байтам содержимого.
+func (za *zipArchive) openEncrypted(file *zip.File, raw io.Reader) (io.ReadCloser, error) {
+	if file.Flags&zipFlagStrongEncryption != 0 {
+		return nil, fmt.Errorf("%w: элемент %s использует PKWARE Strong Encryption", server.ErrUnsupportedEncryption, file.Name)
+	}
+	if za.password == "" {
+		return nil, fmt.Errorf("%w: элемент %s", server.ErrPasswordRequired, file.Name)
+	}
+
+	var (
+		content *checkedReader
+		err     error
+	)
+	if file.Method == zipMethodAES {
+		content, err = openWinZipAES(file, raw, za.password)
+	} else {
+		content, err = openZipCrypto(file, raw, za.password)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Неверный пароль ZipCrypto проходит проверку контрольного байта
+	// с вероятностью 1/256, поэтому читаем начало содержимого заранее.
+	// Неверным паролем считаются только ошибки распаковки и проверки
+	// содержимого, ошибки чтения из хранилища передаются как есть.
+	buffered := bufio.NewReader(content)
+	if _, err := buffered.Peek(1); err != nil && !errors.Is(err, io.EOF) {
+		content.Close()
+		if isDecryptionFailure(err) {
+			return nil, fmt.Errorf("%w: элемент %s", server.ErrWrongPassword, file.Name)
+		}
+		return nil, fmt.Errorf("ошибка при чтении элемента %s: %w", file.Name, err)
+	}
+
+	return &bufferedReadCloser{Reader: buffered, Closer: content}, nil
+}
+
+// isDecryptionFailure сообщает, что расшифрованные данные оказались
+// мусором: поток deflate поврежден или не сошлась контрольная сумма
+func isDecryptionFailure(err error) bool {
+	if errors.Is(err, errAuthCode) {
+		return false
+	}
+	var corrupt flate.CorruptInputError
+	return errors.As(err, &corrupt) || errors.Is(err, zip.ErrChecksum)
+}
+
+type bufferedReadCloser struct {
+	*bufio.Reader
+	io.Closer
+}
+
+func openZipCrypto(file *zip.File, raw io.Reader, password server.ArchivePassword) (*checkedReader, error) {
+	keys := newZipCryptoKeys([]byte(password))
+	decrypted := &zipCryptoReader{reader: raw, keys: keys}
+
+	header := make([]byte, zipCryptoHeaderLen)
+	if _, err := io.ReadFull(decrypted, header); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении заголовка шифрования %s: %w", file.Name, err)
+	}
+
+	// Последний байт заголовка совпадает со старшим байтом CRC32 или,
+	// если CRC32 записан после данных, времени изменения
+	check := byte(file.CRC32 >> 24)
+	if file.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(file.ModifiedTime >> 8)
+	}
+	if header[zipCryptoHeaderLen-1] != check {
+		return nil, fmt.Errorf("%w: элемент %s", server.ErrWrongPassword, file.Name)
+	}
+
+	decompressed, err := decompressZipMember(file.Name, file.Method, decrypted)
+	if err != nil {
+		return nil, err
+	}
+	return newCheckedReader(file, decompressed, true, nil), nil
+}
+
+func openWinZipAES(file *zip.File, raw io.Reader, password server.ArchivePassword) (*checkedReader, error) {
+	keyLen, vendorVersion, method, err := parseAESExtra(file.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("элемент %s: %w", file.Name, err)
+	}
+
+	saltLen := keyLen / 2
+	dataLen := int64(file.CompressedSize64) - int64(saltLen+aesVerifierLen+aesAuthCodeLen)
+	if dataLen < 0 {
+		return nil, fmt.Errorf("элемент %s: некорректный размер зашифрованных данных", file.Name)
+	}
+
+	header := make([]byte, saltLen+aesVerifierLen)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении заголовка шифрования %s: %w", file.Name, err)
+	}
+	salt, verifier := header[:saltLen], header[saltLen:]
+
+	keys, err := pbkdf2.Key(sha1.New, string(password), salt, aesIterations, 2*keyLen+aesVerifierLen)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ключ шифрования: %w", err)
+	}
+	if !hmac.Equal(keys[2*keyLen:], verifier) {
+		return nil, fmt.Errorf("%w: элемент %s", server.ErrWrongPassword, file.Name)
+	}
+
+	block, err := aes.NewCipher(keys[:keyLen])
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать шифр: %w", err)
+	}
+
+	// Код аутентификации считается по зашифрованным данным
+	mac := hmac.New(sha1.New, keys[keyLen:2*keyLen])
+	encrypted := io.TeeReader(io.LimitReader(raw, dataLen), mac)
+
+	decompressed, err := decompressZipMember(file.Name, method, newWinZipAESReader(encrypted, block))
+	if err != nil {
+		return nil, err
+	}
+
+	verify := func() error {
+		if _, err := io.Copy(io.Discard, encrypted); err != nil {
+			return err
+		}
+		authCode := make([]byte, aesAuthCodeLen)
+		if _, err := io.ReadFull(raw, authCode); err != nil {
+			return fmt.Errorf("ошибка при чтении кода аутентификации: %w", err)
+		}
+		if !hmac.Equal(mac.Sum(nil)[:aesAuthCodeLen], authCode) {
+			return fmt.Errorf("элемент %s: %w: %w", file.Name, errAuthCode, zip.ErrChecksum)
+		}
+		return nil
+	}
+
+	// В AE-2 поле CRC32 не заполняется, целостность проверяет код аутентификации
+	return newCheckedReader(file, decompressed, vendorVersion == aesVendorVersionAE1, verify), nil
+}
+
+// parseAESExtra читает из дополнительного поля 0x9901 длину ключа AES,
+// версию формата и настоящий метод сжатия
+func parseAESExtra(extra []byte) (keyLen int, vendorVersion, method uint16, err error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		field := extra[:size]
+		extra = extra[size:]
+
+		if id != aesExtraID {
+			continue
+		}
+		if size < 7 || string(field[2:4]) != "AE" {
+			break
+		}
+
+		vendorVersion = binary.LittleEndian.Uint16(field[0:2])
+		method = binary.LittleEndian.Uint16(field[5:7])
+		switch field[4] {
+		case 1:
+			return 16, vendorVersion, method, nil
+		case 2:
+			return 24, vendorVersion, method, nil
+		case 3:
+			return 32, vendorVersion, method, nil
+		}
+		return 0, 0, 0, fmt.Errorf("%w: длина ключа AES %d", server.ErrUnsupportedEncryption, field[4])
+	}
+	return 0, 0, 0, fmt.Errorf("%w: нет параметров WinZip AES", server.ErrUnsupportedEncryption)
+}
+
+func decompressZipMember(name string, method uint16, content io.Reader) (io.ReadCloser, error) {
+	switch method {
+	case zip.Store:
+		return io.NopCloser(content), nil
+	case zip.Deflate:
+		return flate.NewReader(content), nil
+	}
+	return nil, fmt.Errorf("элемент %s: метод сжатия %d: %w", name, method, zip.ErrAlgorithm)
+}
+
+// checkedReader сверяет размер и CRC32 распакованного содержимого
+// по окончании чтения. У зашифрованного элемента несовпадение чаще
+// всего означает неверный пароль.
+type checkedReader struct {
+	name     string
+	content  io.ReadCloser
+	hash     hash.Hash32
+	checkCRC bool
+	crc32    uint32
+	size     int64
+	read     int64
+	verify   func() error
+}
+
+func newCheckedReader(file *zip.File, content io.ReadCloser, checkCRC bool, verify func() error) *checkedReader {
+	return &checkedReader{
+		name:     file.Name,
+		content:  content,
+		hash:     crc32.NewIEEE(),
+		checkCRC: checkCRC,
+		crc32:    file.CRC32,
+		size:     int64(file.UncompressedSize64),
+		verify:   verify,
+	}
+}
+
+func (cr *checkedReader) Read(p []byte) (int, error) {
+	n, err := cr.content.Read(p)
+	cr.hash.Write(p[:n])
+	cr.read += int64(n)
+
+	if errors.Is(err, io.EOF) {
+		if checkErr := cr.check(); checkErr != nil {
+			return n, checkErr
+		}
+	}
+	return n, err
+}
+
+func (cr *checkedReader) check() error {
+	if cr.read != cr.size {
+		return fmt.Errorf("размер элемента %s не совпал: %w", cr.name, zip.ErrChecksum)
+	}
+	if cr.checkCRC && cr.hash.Sum32() != cr.crc32 {
+		return fmt.Errorf("CRC32 элемента %s не совпал: %w", cr.name, zip.ErrChecksum)
+	}
+	if cr.verify != nil {
+		return cr.verify()
+	}
+	return nil
+}
+
+func (cr *checkedReader) Close() error {
+	return cr.content.Close()
+}
+
+// zipCryptoKeys — состояние традиционного шифрования PKWARE
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password []byte) *zipCryptoKeys {
+	keys := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for _, b := range password {
+		keys.update(b)
+	}
+	return keys
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32Step(k[0], b)
+	k[1] += k[0] & 0xff
+	k[1] = k[1]*134775813 + 1
+	k[2] = crc32Step(k[2], byte(k[1]>>24))
+}
+
+func (k *zipCryptoKeys) decrypt(b byte) byte {
+	temp := k[2] | 2
+	plain := b ^ byte((temp*(temp^1))>>8)
+	k.update(plain)
+	return plain
+}
+
+func crc32Step(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+type zipCryptoReader struct {
+	reader io.Reader
+	keys   *zipCryptoKeys
+}
+
+func (zr *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := zr.reader.Read(p)
+	for i := range p[:n] {
+		p[i] = zr.keys.decrypt(p[i])
+	}
+	return n, err
+}
+
+// winZipAESReader — AES в режиме CTR со счетчиком в порядке little-endian,
+// начинающимся с единицы, как в WinZip
+type winZipAESReader struct {
+	reader    io.Reader
+	block     cipher.Block
+	counter   [aes.BlockSize]byte
+	keystream [aes.BlockSize]byte
+	used      int
+}
+
+func newWinZipAESReader(reader io.Reader, block cipher.Block) *winZipAESReader {
+	return &winZipAESReader{reader: reader, block: block, used: aes.BlockSize}
+}
+
+func (ar *winZipAESReader) Read(p []byte) (int, error) {
+	n, err := ar.reader.Read(p)
+	for i := range p[:n] {
+		if ar.used == aes.BlockSize {
+			ar.nextBlock()
+		}
+		p[i] ^= ar.keystream[ar.used]
+		ar.used++
+	}
+	return n, err
+}
+
+func (ar *winZipAESReader) nextBlock() {
+	for i := range ar.counter {
+		ar.counter[i]++
+		if ar.counter[i] != 0 {
+			break
+		}
+	}
+	ar.block.Encrypt(ar.keystream[:], ar.counter[:])
+	ar.used = 0
+}
@@ -0,0 +1,114 @@
+package minio
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"s3_multiclient/server"
+	"strings"
+	"testing"
+)
+
+// Архивы в testdata содержат один элемент secret.txt с паролем "secret":
+// zipcrypto.zip создан zip -P, aes256.zip — bsdtar с шифрованием AES-256
+const (
+	fixturePassword = server.ArchivePassword("secret")
+	fixtureMember   = "secret.txt"
+)
+
+var fixtureContent = strings.Repeat("hello secret world\n", 20)
+
+func TestOpenEncrypted(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		password server.ArchivePassword
+		wantErr  error
+	}{
+		{"zipcrypto correct password", "zipcrypto.zip", fixturePassword, nil},
+		{"zipcrypto wrong password", "zipcrypto.zip", "wrong", server.ErrWrongPassword},
+		{"zipcrypto missing password", "zipcrypto.zip", "", server.ErrPasswordRequired},
+		{"aes correct password", "aes256.zip", fixturePassword, nil},
+		{"aes wrong password", "aes256.zip", "wrong", server.ErrWrongPassword},
+		{"aes missing password", "aes256.zip", "", server.ErrPasswordRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := readFixtureMember(t, readFixture(t, tt.fixture), tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if content != fixtureContent {
+				t.Fatalf("содержимое %q, ожидалось %q", content, fixtureContent)
+			}
+		})
+	}
+}
+
+func TestOpenEncryptedCorruptedAuthCode(t *testing.T) {
+	data := readFixture(t, "aes256.zip")
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := reader.File[0]
+	offset, err := file.DataOffset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Код аутентификации занимает последние байты сжатых данных
+	data[offset+int64(file.CompressedSize64)-1] ^= 0xff
+
+	_, err = readFixtureMember(t, data, fixturePassword)
+	if !errors.Is(err, zip.ErrChecksum) {
+		t.Fatalf("ошибка %v, ожидалась %v", err, zip.ErrChecksum)
+	}
+	if errors.Is(err, server.ErrWrongPassword) {
+		t.Fatalf("поврежденный код аутентификации принят за неверный пароль: %v", err)
+	}
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func readFixtureMember(t *testing.T, data []byte, password server.ArchivePassword) (string, error) {
+	t.Helper()
+
+	archive, err := newZipArchive(bytes.NewReader(data), int64(len(data)), password, func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	entry, err := archive.Select(&server.MemberSelector{Path: fixtureMember})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	member, err := archive.Open(entry, nil)
+	if err != nil {
+		return "", err
+	}
+	defer member.Close()
+
+	content, err := io.ReadAll(member)
+	return string(content), err
+}
@@ -0,0 +1,77 @@
+package minio
+
+import (
+	"container/list"
+	"sync"
+)
+
+// zipDirectoryCache — LRU разобранных каталогов ZIP. Ключ включает ETag,
+// поэтому после перезаписи объекта старый каталог не используется и со
+// временем вытесняется. Ограничен числом архивов и суммарным числом файлов
+// в них; при maxDirectories == 0 кеш отключен.
+type zipDirectoryCache struct {
+	mu             sync.Mutex
+	maxDirectories int
+	maxFiles       int
+	files          int
+	order          *list.List
+	items          map[string]*list.Element
+}
+
+type cachedZipDirectory struct {
+	key string
+	dir *zipDirectory
+}
+
+func newZipDirectoryCache(maxDirectories, maxFiles int) *zipDirectoryCache {
+	return &zipDirectoryCache{
+		maxDirectories: maxDirectories,
+		maxFiles:       maxFiles,
+		order:          list.New(),
+		items:          make(map[string]*list.Element),
+	}
+}
+
+func zipDirectoryCacheKey(key, etag string) string {
+	return key + "\x00" + etag
+}
+
+func (c *zipDirectoryCache) get(key, etag string) (*zipDirectory, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[zipDirectoryCacheKey(key, etag)]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedZipDirectory).dir, true
+}
+
+func (c *zipDirectoryCache) put(key, etag string, dir *zipDirectory) {
+	// Без ETag нельзя отличить новую версию объекта от старой
+	if c.maxDirectories == 0 || etag == "" || len(dir.entries) > c.maxFiles {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cacheKey := zipDirectoryCacheKey(key, etag)
+	if element, ok := c.items[cacheKey]; ok {
+		c.remove(element)
+	}
+
+	c.items[cacheKey] = c.order.PushFront(&cachedZipDirectory{key: cacheKey, dir: dir})
+	c.files += len(dir.entries)
+
+	for c.order.Len() > c.maxDirectories || c.files > c.maxFiles {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *zipDirectoryCache) remove(element *list.Element) {
+	cached := c.order.Remove(element).(*cachedZipDirectory)
+	delete(c.items, cached.key)
+	c.files -= len(cached.dir.entries)
+}
@@ -0,0 +1,107 @@
+// Package sniff определяет формат содержимого по первым байтам
+package sniff
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// PrefixSize — сколько первых байт нужно для уверенного определения формата.
+// Для сжатых tar этого обычно хватает, чтобы распаковать первый заголовок.
+const PrefixSize = 8 * 1024
+
+// Форматы контейнеров, из которых сервис умеет извлекать элементы
+const (
+	ContainerNone   = ""
+	ContainerZip    = "zip"
+	ContainerTar    = "tar"
+	ContainerTarGz  = "tar.gz"
+	ContainerTarZst = "tar.zst"
+)
+
+const tarBlockSize = 512
+
+var (
+	zipLocalHeader    = []byte("PK\x03\x04")
+	zipEmptyArchive   = []byte("PK\x05\x06")
+	zipSpannedArchive = []byte("PK\x07\x08")
+	gzipMagic         = []byte{0x1f, 0x8b, 0x08}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectContainer определяет формат архива по сигнатуре. Если сигнатура не
+// распознана или префикса не хватило, возвращается ContainerNone.
+func DetectContainer(prefix []byte) string {
+	switch {
+	case bytes.HasPrefix(prefix, zipLocalHeader),
+		bytes.HasPrefix(prefix, zipEmptyArchive),
+		bytes.HasPrefix(prefix, zipSpannedArchive):
+		return ContainerZip
+	case bytes.HasPrefix(prefix, gzipMagic):
+		if isTarHeader(decompressedPrefix(prefix, ContainerTarGz)) {
+			return ContainerTarGz
+		}
+	case bytes.HasPrefix(prefix, zstdMagic):
+		if isTarHeader(decompressedPrefix(prefix, ContainerTarZst)) {
+			return ContainerTarZst
+		}
+	case isTarHeader(prefix):
+		return ContainerTar
+	}
+	return ContainerNone
+}
+
+func decompressedPrefix(prefix []byte, kind string) []byte {
+	var reader io.Reader
+	switch kind {
+	case ContainerTarGz:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(prefix))
+		if err != nil {
+			return nil
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case ContainerTarZst:
+		zstdReader, err := zstd.NewReader(bytes.NewReader(prefix), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	}
+
+	block := make([]byte, tarBlockSize)
+	n, _ := io.ReadFull(reader, block)
+	return block[:n]
+}
+
+// isTarHeader проверяет магию ustar, а для старых заголовков без нее —
+// контрольную сумму заголовка
+func isTarHeader(block []byte) bool {
+	if len(block) < tarBlockSize {
+		return false
+	}
+	if bytes.HasPrefix(block[257:], []byte("ustar")) {
+		return true
+	}
+
+	checksumField := strings.Trim(string(block[148:156]), " \x00")
+	expected, err := strconv.ParseUint(checksumField, 8, 32)
+	if err != nil || block[0] == 0 {
+		return false
+	}
+
+	var sum uint64
+	for i, b := range block[:tarBlockSize] {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += uint64(b)
+	}
+	return sum == expected
+}
@@ -0,0 +1,314 @@
+package sniff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// UnknownContentType — результат DetectContentType, если формат не распознан
+const UnknownContentType = "application/octet-stream"
+
+const textPrefix = "text/"
+
+var zipDataDescriptor = []byte("PK\x07\x08")
+
+type signature struct {
+	magic       []byte
+	contentType string
+}
+
+// signatures дополняют http.DetectContentType форматами, которых нет
+// в его таблице. Проверяются до него.
+var signatures = []signature{
+	{[]byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"), "application/x-ole-storage"},
+	{[]byte("7z\xBC\xAF\x27\x1C"), "application/x-7z-compressed"},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "application/x-xz"},
+	{[]byte("BZh"), "application/x-bzip2"},
+	{zstdMagic, "application/zstd"},
+	{[]byte("fLaC"), "audio/flac"},
+	{[]byte("%!PS"), "application/postscript"},
+	{[]byte("II*\x00"), "image/tiff"},
+	{[]byte("MM\x00*"), "image/tiff"},
+}
+
+// ftypBrands — типы ISO BMFF по основному бренду в заголовке ftyp
+var ftypBrands = map[string]string{
+	"qt  ": "video/quicktime",
+	"M4A ": "audio/mp4",
+	"M4B ": "audio/mp4",
+	"M4V ": "video/x-m4v",
+	"heic": "image/heic",
+	"heix": "image/heic",
+	"mif1": "image/heif",
+	"avif": "image/avif",
+	"3gp4": "video/3gpp",
+	"3gp5": "video/3gpp",
+	"3g2a": "video/3gpp2",
+}
+
+// zipMembers — типы документов на основе ZIP по именам первых элементов
+var zipMembers = []struct {
+	prefix      string
+	contentType string
+}{
+	{"word/", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	{"xl/", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	{"ppt/", "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+	{"AndroidManifest.xml", "application/vnd.android.package-archive"},
+	{"META-INF/MANIFEST.MF", "application/java-archive"},
+}
+
+// DetectContentType определяет MIME-тип по первым байтам. Кроме таблицы
+// http.DetectContentType распознаются документы Office и OpenDocument,
+// JAR и APK внутри ZIP, контейнеры ISO BMFF по бренду, Matroska, tar
+// и ряд архивов. Для нераспознанных данных возвращается
+// application/octet-stream.
+func DetectContentType(prefix []byte) string {
+	if len(prefix) == 0 {
+		return UnknownContentType
+	}
+
+	if bytes.HasPrefix(prefix, zipLocalHeader) {
+		if contentType := zipDocumentType(prefix); contentType != "" {
+			return contentType
+		}
+	}
+	if contentType := ftypType(prefix); contentType != "" {
+		return contentType
+	}
+	if contentType := matroskaType(prefix); contentType != "" {
+		return contentType
+	}
+	for _, sig := range signatures {
+		if bytes.HasPrefix(prefix, sig.magic) {
+			return sig.contentType
+		}
+	}
+	if isTarHeader(prefix) {
+		return "application/x-tar"
+	}
+
+	return http.DetectContentType(prefix)
+}
+
+// IsSpecific сообщает, что тип определен по сигнатуре. Текстовые типы
+// http.DetectContentType выводит из отсутствия двоичных байт, поэтому
+// они не считаются определенными: CSV, JSON и исходники неотличимы.
+func IsSpecific(contentType string) bool {
+	mediaType := baseType(contentType)
+	return mediaType != "" && mediaType != UnknownContentType && !strings.HasPrefix(mediaType, textPrefix)
+}
+
+// Compatible сообщает, не противоречит ли тип candidate содержимому
+// с типом sniffed. Тип контейнера совместим с форматами на его основе:
+// ZIP — с документами Office и JAR, OLE2 — со старыми форматами Office.
+func Compatible(sniffed, candidate string) bool {
+	sniffed, candidate = canonicalType(sniffed), canonicalType(candidate)
+	if sniffed == candidate {
+		return true
+	}
+
+	if strings.HasPrefix(sniffed, textPrefix) {
+		// Для текста подходит любой текстовый формат, но не двоичный
+		switch {
+		case strings.HasPrefix(candidate, "image/"):
+			return strings.HasSuffix(candidate, "+xml")
+		case strings.HasPrefix(candidate, "audio/"), strings.HasPrefix(candidate, "video/"):
+			return false
+		}
+		return !IsSpecific(candidate) || isTextApplication(candidate)
+	}
+
+	for _, family := range containerFamilies[sniffed] {
+		if strings.HasPrefix(candidate, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerFamilies — префиксы типов, которые хранятся в контейнере
+var containerFamilies = map[string][]string{
+	"application/zip": {
+		"application/vnd.openxmlformats-officedocument.",
+		"application/vnd.oasis.opendocument.",
+		"application/vnd.ms-",
+		"application/epub+zip",
+		"application/java-archive",
+		"application/vnd.android.package-archive",
+		"application/x-zip",
+		"model/vnd.usdz+zip",
+	},
+	"application/x-ole-storage": {
+		"application/msword",
+		"application/vnd.ms-",
+		"application/x-msi",
+		"application/x-ole",
+	},
+	"application/gzip": {
+		"application/x-gtar",
+		"application/x-compressed-tar",
+		"application/x-tgz",
+	},
+	"video/mp4": {
+		"audio/mp4",
+		"video/x-m4v",
+	},
+	"video/webm": {
+		"audio/webm",
+		"video/x-matroska",
+	},
+	"application/ogg": {
+		"audio/ogg",
+		"video/ogg",
+	},
+}
+
+// typeAliases сводит разные имена одного формата к одному
+var typeAliases = map[string]string{
+	"application/x-gzip":           "application/gzip",
+	"application/x-zip-compressed": "application/zip",
+	"application/x-rar-compressed": "application/vnd.rar",
+	"application/x-rar":            "application/vnd.rar",
+	"application/x-pdf":            "application/pdf",
+	"audio/wav":                    "audio/wave",
+	"audio/x-wav":                  "audio/wave",
+	"audio/vnd.wave":               "audio/wave",
+	"audio/mp3":                    "audio/mpeg",
+	"audio/x-flac":                 "audio/flac",
+	"audio/x-aiff":                 "audio/aiff",
+	"video/x-msvideo":              "video/avi",
+	"image/jpg":                    "image/jpeg",
+	"image/pjpeg":                  "image/jpeg",
+	"image/x-png":                  "image/png",
+	"image/x-ms-bmp":               "image/bmp",
+	"image/vnd.microsoft.icon":     "image/x-icon",
+	"application/x-zstd":           "application/zstd",
+	"application/x-7z":             "application/x-7z-compressed",
+}
+
+func isTextApplication(mediaType string) bool {
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-ndjson",
+		"application/x-sh", "application/sql", "application/x-yaml", "application/yaml", "application/toml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+func baseType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+func canonicalType(contentType string) string {
+	mediaType := baseType(contentType)
+	if alias, ok := typeAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+// zipDocumentType проходит по локальным заголовкам ZIP в пределах префикса
+// и определяет документ по именам элементов. OpenDocument и EPUB хранят
+// свой тип первым несжатым элементом mimetype.
+func zipDocumentType(prefix []byte) string {
+	const headerSize = 30
+
+	for offset := 0; offset+headerSize <= len(prefix); {
+		header := prefix[offset:]
+		if !bytes.HasPrefix(header, zipLocalHeader) {
+			return ""
+		}
+
+		flags := binary.LittleEndian.Uint16(header[6:])
+		method := binary.LittleEndian.Uint16(header[8:])
+		compressedSize := int(binary.LittleEndian.Uint32(header[18:]))
+		nameLength := int(binary.LittleEndian.Uint16(header[26:]))
+		extraLength := int(binary.LittleEndian.Uint16(header[28:]))
+
+		nameEnd := headerSize + nameLength
+		if nameEnd > len(header) {
+			return ""
+		}
+		name := string(header[headerSize:nameEnd])
+		dataStart := min(nameEnd+extraLength, len(header))
+
+		// Если размер записан в дескрипторе после данных, конец данных
+		// ищется по сигнатуре следующего заголовка
+		dataEnd := dataStart + compressedSize
+		if flags&0x8 != 0 {
+			next := bytes.Index(header[dataStart:], zipLocalHeader)
+			if next < 0 {
+				next = len(header) - dataStart
+			}
+			dataEnd = dataStart + next
+		}
+
+		if name == "mimetype" && method == 0 && dataEnd <= len(header) {
+			if mediaType := leadingMediaType(header[dataStart:dataEnd]); mediaType != "" {
+				return mediaType
+			}
+		}
+		for _, member := range zipMembers {
+			if strings.HasPrefix(name, member.prefix) {
+				return member.contentType
+			}
+		}
+
+		offset += dataEnd
+	}
+	return ""
+}
+
+// leadingMediaType читает MIME-тип в начале data: после содержимого
+// mimetype может сразу идти дескриптор данных
+func leadingMediaType(data []byte) string {
+	if descriptor := bytes.Index(data, zipDataDescriptor); descriptor >= 0 {
+		data = data[:descriptor]
+	}
+
+	end := 0
+	for end < len(data) && isMediaTypeByte(data[end]) {
+		end++
+	}
+
+	mediaType, _, err := mime.ParseMediaType(string(data[:end]))
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return ""
+	}
+	return mediaType
+}
+
+func isMediaTypeByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || strings.IndexByte("/.+-", b) >= 0
+}
+
+func ftypType(prefix []byte) string {
+	if len(prefix) < 12 || !bytes.Equal(prefix[4:8], []byte("ftyp")) {
+		return ""
+	}
+	return ftypBrands[string(prefix[8:12])]
+}
+
+// matroskaType различает Matroska и WebM по DocType в заголовке EBML
+func matroskaType(prefix []byte) string {
+	if !bytes.HasPrefix(prefix, []byte{0x1a, 0x45, 0xdf, 0xa3}) {
+		return ""
+	}
+
+	header := prefix[:min(len(prefix), 64)]
+	switch {
+	case bytes.Contains(header, []byte("matroska")):
+		return "video/x-matroska"
+	case bytes.Contains(header, []byte("webm")):
+		return "video/webm"
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package sniff
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix []byte
+		want   string
+	}{
+		{"empty", nil, UnknownContentType},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"pdf", []byte("%PDF-1.7\n"), "application/pdf"},
+		{"plain text", []byte("hello, world\n"), "text/plain; charset=utf-8"},
+		{"ole2", []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00"), "application/x-ole-storage"},
+		{"7z", []byte("7z\xBC\xAF\x27\x1C\x00\x04"), "application/x-7z-compressed"},
+		{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x04, 0x00}, "application/zstd"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{"quicktime", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00"), "video/quicktime"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{"mp4", []byte("\x00\x00\x00\x1cftypisom\x00\x00\x02\x00isomiso2mp41"), "video/mp4"},
+		{"matroska", []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x88matroska"), "video/x-matroska"},
+		{"webm", []byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm"), "video/webm"},
+		{"zip", zipWith(t, "readme.txt"), "application/zip"},
+		{"docx", zipWith(t, "[Content_Types].xml", "word/document.xml"), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"xlsx", zipWith(t, "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"jar", zipWith(t, "META-INF/MANIFEST.MF"), "application/java-archive"},
+		{"opendocument", openDocument(t, "application/vnd.oasis.opendocument.text"), "application/vnd.oasis.opendocument.text"},
+		{"tar", tarWith(t, "readme.txt"), "application/x-tar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.prefix); got != tt.want {
+				t.Fatalf("DetectContentType() = %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	tests := []struct {
+		sniffed   string
+		candidate string
+		want      bool
+	}{
+		{"image/png", "image/png", true},
+		{"image/png", "image/png; charset=binary", true},
+		{"image/jpeg", "image/jpg", true},
+		{"application/gzip", "application/x-gzip", true},
+		{"application/zip", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", true},
+		{"application/zip", "application/java-archive", true},
+		{"application/zip", "application/epub+zip", true},
+		{"application/x-ole-storage", "application/msword", true},
+		{"application/gzip", "application/x-compressed-tar", true},
+		{"video/mp4", "audio/mp4", true},
+		{"video/webm", "audio/webm", true},
+		{"text/plain; charset=utf-8", "text/csv", true},
+		{"text/plain; charset=utf-8", "application/json", true},
+		{"text/plain; charset=utf-8", "image/svg+xml", true},
+
+		{"image/png", "image/jpeg", false},
+		{"application/pdf", "application/zip", false},
+		{"application/java-archive", "application/zip", false},
+		{"text/plain; charset=utf-8", "image/png", false},
+		{"text/plain; charset=utf-8", "video/mp4", false},
+		{"text/plain; charset=utf-8", "application/pdf", false},
+		{"application/x-ole-storage", "application/zip", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sniffed+" "+tt.candidate, func(t *testing.T) {
+			if got := Compatible(tt.sniffed, tt.candidate); got != tt.want {
+				t.Fatalf("Compatible(%q, %q) = %v, ожидалось %v", tt.sniffed, tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
+
+func zipWith(t *testing.T, names ...string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	zw := zip.NewWriter(&buffer)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("content")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// openDocument собирает архив, как OpenDocument: первым идет несжатый
+// элемент mimetype
+func openDocument(t *testing.T, mediaType string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	zw := zip.NewWriter(&buffer)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(mediaType)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Create("content.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func tarWith(t *testing.T, name string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	tw := tar.NewWriter(&buffer)
+	content := []byte("content")
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,20 @@
+package load
+
+import (
+	"context"
+	"s3_multiclient/server"
+)
+
+func (l *Loader) Extract(ctx context.Context, data *server.ExtractRequestMetadata) error {
+	if err := l.fileManager.ExtractFile(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) AppendEntries(ctx context.Context, data *server.AppendEntriesRequestMetadata) error {
+	if err := l.fileManager.AppendArchiveEntries(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
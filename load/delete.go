@@ -2,7 +2,26 @@ package load
 
 import (
 	"context"
-	"net/http"
+	"s3_multiclient/server"
 )
 
-func (l *Loader) Delete(w http.ResponseWriter, r *http.Request, ctx context.Context) error {return nil}
+func (l *Loader) Delete(ctx context.Context, data *server.DeleteRequestMetadata) error {
+	if err := l.fileManager.DeleteFile(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) DeleteBatch(ctx context.Context, data *server.BatchDeleteRequestMetadata) error {
+	if err := l.fileManager.DeleteFiles(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) Restore(ctx context.Context, data *server.RestoreRequestMetadata) error {
+	if err := l.fileManager.RestoreFile(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
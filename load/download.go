@@ -14,3 +14,29 @@ func (l *Loader) Download(w http.ResponseWriter, ctx context.Context, data *serv
 	}
 	return nil
 }
+
+func (l *Loader) DownloadBundle(w http.ResponseWriter, ctx context.Context, data *server.BundleRequestMetadata) error {
+	pw := newProgressWriter(w)
+
+	if err := l.fileManager.DownloadBundle(ctx, pw, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) DownloadFolder(w http.ResponseWriter, ctx context.Context, data *server.FolderRequestMetadata) error {
+	pw := newProgressWriter(w)
+
+	if err := l.fileManager.DownloadFolder(ctx, pw, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) Stat(ctx context.Context, data *server.DownloadRequestMetadata) (*server.ObjectMetadata, error) {
+	return l.fileManager.StatFile(ctx, data)
+}
+
+func (l *Loader) ListEntries(ctx context.Context, data *server.DownloadRequestMetadata) ([]*server.ArchiveEntry, error) {
+	return l.fileManager.ListArchiveEntries(ctx, data)
+}
@@ -8,7 +8,23 @@ import (
 type FileManager interface {
 	UploadFile(ctx context.Context, progressReader *ProgressReader, data *server.UploadRequestMetadata) error
 	DownloadFile(ctx context.Context, pw *ProgressWriter, data *server.DownloadRequestMetadata) error
-	DeleteFile(ctx context.Context, objectID string) error
+	DownloadBundle(ctx context.Context, pw *ProgressWriter, data *server.BundleRequestMetadata) error
+	DownloadFolder(ctx context.Context, pw *ProgressWriter, data *server.FolderRequestMetadata) error
+	StatFile(ctx context.Context, data *server.DownloadRequestMetadata) (*server.ObjectMetadata, error)
+	ListArchiveEntries(ctx context.Context, data *server.DownloadRequestMetadata) ([]*server.ArchiveEntry, error)
+	DeleteFile(ctx context.Context, data *server.DeleteRequestMetadata) error
+	DeleteFiles(ctx context.Context, data *server.BatchDeleteRequestMetadata) error
+	RestoreFile(ctx context.Context, data *server.RestoreRequestMetadata) error
+	ExtractFile(ctx context.Context, data *server.ExtractRequestMetadata) error
+	AppendArchiveEntries(ctx context.Context, data *server.AppendEntriesRequestMetadata) error
+	CreateResumableUpload(ctx context.Context, data *server.ResumableUploadMetadata) error
+	GetResumableUpload(ctx context.Context, data *server.ResumableUploadMetadata) error
+	WriteResumableUpload(ctx context.Context, progressReader *ProgressReader, data *server.ResumableUploadMetadata) error
+	CreateMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error
+	PutMultipartPart(ctx context.Context, progressReader *ProgressReader, data *server.MultipartUploadMetadata) error
+	ListMultipartParts(ctx context.Context, data *server.MultipartUploadMetadata) error
+	CompleteMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error
+	AbortMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error
 }
 
 type Loader struct {
@@ -0,0 +1,44 @@
+package load
+
+import (
+	"context"
+	"io"
+	"s3_multiclient/server"
+)
+
+func (l *Loader) InitiateMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := l.fileManager.CreateMultipartUpload(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) UploadPart(body io.Reader, ctx context.Context, data *server.MultipartUploadMetadata) error {
+	progressReader := newProgressReader(body)
+
+	if err := l.fileManager.PutMultipartPart(ctx, progressReader, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) ListParts(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := l.fileManager.ListMultipartParts(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) CompleteMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := l.fileManager.CompleteMultipartUpload(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) AbortMultipartUpload(ctx context.Context, data *server.MultipartUploadMetadata) error {
+	if err := l.fileManager.AbortMultipartUpload(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
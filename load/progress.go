@@ -1,6 +1,7 @@
 package load
 
 import (
+	"io"
 	"log/slog"
 	"net/http"
 	"time"
@@ -29,22 +30,23 @@ func (pw *ProgressWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+// ProgressReader считает байты, прочитанные из тела запроса или части формы
 type ProgressReader struct {
-	*http.Request
+	io.Reader
 	TotalBytes  int64
 	ChunkCount  int
 	LastLogTime time.Time
 }
 
-func newProgressReader(r *http.Request) *ProgressReader {
+func newProgressReader(body io.Reader) *ProgressReader {
 	return &ProgressReader{
-		Request:     r,
+		Reader:      body,
 		LastLogTime: time.Now(),
 	}
 }
 
 func (pr *ProgressReader) Read(p []byte) (int, error) {
-	n, err := pr.Body.Read(p)
+	n, err := pr.Reader.Read(p)
 	pr.TotalBytes += int64(n)
 	pr.ChunkCount++
 	now := time.Now()
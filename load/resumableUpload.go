@@ -0,0 +1,31 @@
+package load
+
+import (
+	"context"
+	"io"
+	"s3_multiclient/server"
+)
+
+func (l *Loader) CreateUpload(ctx context.Context, data *server.ResumableUploadMetadata) error {
+	if err := l.fileManager.CreateResumableUpload(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) UploadStatus(ctx context.Context, data *server.ResumableUploadMetadata) error {
+	if err := l.fileManager.GetResumableUpload(ctx, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Loader) PatchUpload(body io.Reader, ctx context.Context, data *server.ResumableUploadMetadata) error {
+	progressReader := newProgressReader(body)
+
+	if err := l.fileManager.WriteResumableUpload(ctx, progressReader, data); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,12 +2,12 @@ package load
 
 import (
 	"context"
-	"net/http"
+	"io"
 	"s3_multiclient/server"
 )
 
-func (l *Loader) Upload(r *http.Request, ctx context.Context, data *server.UploadRequestMetadata) error {
-	progressReader := newProgressReader(r)
+func (l *Loader) Upload(body io.Reader, ctx context.Context, data *server.UploadRequestMetadata) error {
+	progressReader := newProgressReader(body)
 
 	if err := l.fileManager.UploadFile(ctx, progressReader, data); err != nil {
 		return err
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+)
+
+const (
+	successfulAppendStatus = "updated"
+	maxAppendEntries       = 1000
+	// appendFormMemory — сколько данных формы держать в памяти,
+	// остальное net/http сохраняет во временные файлы
+	appendFormMemory = 32 * 1024 * 1024
+)
+
+// AppendedEntry — файл, добавляемый в архив или заменяющий элемент
+// с тем же путем
+type AppendedEntry struct {
+	Path string
+	Size int64
+	Open func() (io.ReadCloser, error)
+}
+
+// AppendEntriesRequestMetadata описывает изменение хранимого ZIP.
+// FileName, ContentType и Size заполняются слоем хранилища.
+type AppendEntriesRequestMetadata struct {
+	ID      string
+	Key     string
+	Entries []*AppendedEntry
+
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+// AppendEntries принимает multipart/form-data, где имя каждого поля-файла —
+// путь элемента в архиве. Если архив изменился во время обработки,
+// ответ — 412, изменения не применяются.
+func (s *Server) AppendEntries(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на изменение архива")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getAppendEntriesRequestData(r)
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.AppendEntries(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendObjectResponse(w, http.StatusOK, &objectResponse{
+		Status: successfulAppendStatus,
+		ID:     data.ID,
+		Name:   data.FileName,
+		Type:   data.ContentType,
+		Size:   getSizeMB(data.Size),
+		Bytes:  data.Size,
+	})
+}
+
+func getAppendEntriesRequestData(r *http.Request) (*AppendEntriesRequestMetadata, error) {
+	objectID, err := parseObjectID(r)
+	if err != nil {
+		slog.Error("Не удалось извлечь object_id", "error", err)
+		return nil, err
+	}
+
+	key, err := parseObjectKey(r, objectID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ объекта", "error", err)
+		return nil, err
+	}
+
+	if err := r.ParseMultipartForm(appendFormMemory); err != nil {
+		slog.Error("Ошибка разбора multipart/form-data", "error", err)
+		return nil, fmt.Errorf("некорректное тело запроса: %w", err)
+	}
+
+	data := &AppendEntriesRequestMetadata{ID: objectID, Key: key}
+	for entryPath, files := range r.MultipartForm.File {
+		if len(files) != 1 {
+			return nil, fmt.Errorf("для элемента %q передано %d файлов", entryPath, len(files))
+		}
+		file := files[0]
+		data.Entries = append(data.Entries, &AppendedEntry{
+			Path: entryPath,
+			Size: file.Size,
+			Open: func() (io.ReadCloser, error) { return file.Open() },
+		})
+	}
+
+	if len(data.Entries) == 0 {
+		return nil, fmt.Errorf("не передано ни одного файла")
+	}
+	if len(data.Entries) > maxAppendEntries {
+		return nil, fmt.Errorf("за один запрос можно добавить не более %d файлов", maxAppendEntries)
+	}
+
+	sort.Slice(data.Entries, func(i, j int) bool { return data.Entries[i].Path < data.Entries[j].Path })
+	return data, nil
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// ArchivePasswordHeader — заголовок с паролем зашифрованного ZIP-архива
+const ArchivePasswordHeader = "X-Archive-Password"
+
+// archivePasswordChallenge — WWW-Authenticate ответа 401: пароль
+// передается не через Authorization, а в заголовке ArchivePasswordHeader
+const archivePasswordChallenge = ArchivePasswordHeader + ` realm="archive"`
+
+// ArchivePassword — пароль от архива из запроса. При выводе через fmt и slog
+// заменяется маской, чтобы не попасть в логи.
+type ArchivePassword string
+
+func (p ArchivePassword) String() string {
+	if p == "" {
+		return ""
+	}
+	return "***"
+}
+
+func (p ArchivePassword) GoString() string {
+	return p.String()
+}
+
+func (p ArchivePassword) LogValue() slog.Value {
+	return slog.StringValue(p.String())
+}
+
+func parseArchivePassword(r *http.Request) ArchivePassword {
+	return ArchivePassword(r.Header.Get(ArchivePasswordHeader))
+}
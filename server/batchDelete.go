@@ -0,0 +1,65 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const maxBatchDeleteObjects = 10000
+
+// Статусы удаления объекта в пакетном запросе
+const (
+	DeleteStatusDeleted  = "deleted"
+	DeleteStatusNotFound = "not_found"
+	DeleteStatusError    = "error"
+)
+
+type batchDeleteRequest struct {
+	IDs []string `json:"ids"`
+}
+
+type batchDeleteResponse struct {
+	Results []*BatchDeleteObject `json:"results"`
+}
+
+type BatchDeleteObject struct {
+	ID     string `json:"id"`
+	Key    string `json:"-"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchDeleteRequestMetadata передается в слой хранилища, который
+// заполняет Status и Error у каждого объекта с непустым Key
+type BatchDeleteRequestMetadata struct {
+	Objects []*BatchDeleteObject
+}
+
+func (s *Server) BatchDelete(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на пакетное удаление")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getBatchDeleteRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.DeleteBatch(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendBatchDeleteResponse(w, data)
+}
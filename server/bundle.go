@@ -0,0 +1,50 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const (
+	maxBundleObjects  = 1000
+	defaultBundleName = "archive.zip"
+)
+
+type bundleRequest struct {
+	Objects []string `json:"objects"`
+	Name    string   `json:"name"`
+}
+
+// BundleRequestMetadata описывает ZIP-архив, собираемый на лету из
+// нескольких объектов или элементов архивов
+type BundleRequestMetadata struct {
+	Name  string
+	Items []*DownloadRequestMetadata
+}
+
+func (s *Server) DownloadBundle(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на скачивание нескольких объектов")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getBundleRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.DownloadBundle(w, s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+}
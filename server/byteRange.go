@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const byteRangeUnit = "bytes="
+
+// ByteRange — один диапазон из заголовка Range в исходном виде.
+// Для суффиксного диапазона "bytes=-N" First равен -1, а Last равен N.
+// Для открытого диапазона "bytes=N-" Last равен -1.
+type ByteRange struct {
+	First int64
+	Last  int64
+}
+
+// ContentRange — диапазон, приведенный к размеру конкретного содержимого
+type ContentRange struct {
+	Start  int64
+	Length int64
+	Size   int64
+}
+
+func (cr *ContentRange) End() int64 {
+	return cr.Start + cr.Length - 1
+}
+
+func (cr *ContentRange) Header() string {
+	return fmt.Sprintf("bytes %d-%d/%d", cr.Start, cr.End(), cr.Size)
+}
+
+func UnsatisfiedRangeHeader(size int64) string {
+	return fmt.Sprintf("bytes */%d", size)
+}
+
+// parseRangeHeader разбирает заголовок Range. Несколько диапазонов и
+// синтаксически неверные значения игнорируются, как допускает RFC 9110:
+// в этом случае возвращается nil и клиент получает объект целиком.
+func parseRangeHeader(header string) *ByteRange {
+	header = strings.TrimSpace(header)
+	if !strings.HasPrefix(header, byteRangeUnit) {
+		return nil
+	}
+
+	spec := strings.TrimSpace(strings.TrimPrefix(header, byteRangeUnit))
+	if spec == "" || strings.Contains(spec, ",") {
+		return nil
+	}
+
+	firstStr, lastStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil
+	}
+	firstStr, lastStr = strings.TrimSpace(firstStr), strings.TrimSpace(lastStr)
+
+	if firstStr == "" {
+		suffix, err := strconv.ParseInt(lastStr, 10, 64)
+		if err != nil || suffix < 0 {
+			return nil
+		}
+		return &ByteRange{First: -1, Last: suffix}
+	}
+
+	first, err := strconv.ParseInt(firstStr, 10, 64)
+	if err != nil || first < 0 {
+		return nil
+	}
+	if lastStr == "" {
+		return &ByteRange{First: first, Last: -1}
+	}
+
+	last, err := strconv.ParseInt(lastStr, 10, 64)
+	if err != nil || last < first {
+		return nil
+	}
+	return &ByteRange{First: first, Last: last}
+}
+
+// Resolve приводит диапазон к содержимому размера size.
+// Если диапазон не пересекается с содержимым, возвращается ErrRangeNotSatisfiable.
+func (br *ByteRange) Resolve(size int64) (*ContentRange, error) {
+	if br.First < 0 {
+		if br.Last == 0 || size == 0 {
+			return nil, fmt.Errorf("%w: bytes=-%d", ErrRangeNotSatisfiable, br.Last)
+		}
+		length := min(br.Last, size)
+		return &ContentRange{Start: size - length, Length: length, Size: size}, nil
+	}
+
+	if br.First >= size {
+		return nil, fmt.Errorf("%w: начало %d за пределами размера %d", ErrRangeNotSatisfiable, br.First, size)
+	}
+
+	last := size - 1
+	if br.Last >= 0 && br.Last < last {
+		last = br.Last
+	}
+	return &ContentRange{Start: br.First, Length: last - br.First + 1, Size: size}, nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestConditions — условные заголовки запроса на скачивание
+type RequestConditions struct {
+	IfNoneMatch     string
+	IfModifiedSince time.Time
+	IfRange         string
+}
+
+func parseRequestConditions(r *http.Request) RequestConditions {
+	conditions := RequestConditions{
+		IfNoneMatch: strings.TrimSpace(r.Header.Get("If-None-Match")),
+		IfRange:     strings.TrimSpace(r.Header.Get("If-Range")),
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		if parsed, err := http.ParseTime(since); err == nil {
+			conditions.IfModifiedSince = parsed
+		}
+	}
+	return conditions
+}
+
+// NotModified сообщает, что у клиента актуальная копия и можно ответить 304.
+// If-None-Match имеет приоритет над If-Modified-Since (RFC 9110, 13.2.2).
+func (rc RequestConditions) NotModified(etag string, lastModified time.Time) bool {
+	if rc.IfNoneMatch != "" {
+		return etagListMatches(rc.IfNoneMatch, etag)
+	}
+	if !rc.IfModifiedSince.IsZero() && !lastModified.IsZero() {
+		return !lastModified.Truncate(time.Second).After(rc.IfModifiedSince)
+	}
+	return false
+}
+
+// RangeApplies проверяет If-Range: диапазон отдается, только если
+// валидатор клиента совпадает с текущей версией содержимого
+func (rc RequestConditions) RangeApplies(etag string, lastModified time.Time) bool {
+	if rc.IfRange == "" {
+		return true
+	}
+	if strings.HasPrefix(rc.IfRange, `"`) {
+		return rc.IfRange == etag
+	}
+	since, err := http.ParseTime(rc.IfRange)
+	if err != nil {
+		return false
+	}
+	return lastModified.Truncate(time.Second).Equal(since)
+}
+
+// QuoteETag приводит ETag к виду, в котором он передается в заголовках
+func QuoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagListMatches сравнивает ETag со списком из If-None-Match по слабому правилу
+func etagListMatches(list, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for candidate := range strings.SplitSeq(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
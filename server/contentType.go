@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"s3_multiclient/config"
+	"s3_multiclient/file/sniff"
+)
+
+// resolveContentType выбирает Content-Type загружаемого файла из трех
+// источников: заголовка клиента, расширения имени и сигнатуры первых байт.
+// Порядок доверия задает политика из UPLOAD_CONTENT_TYPE_POLICY. Тип,
+// согласный с содержимым, предпочитается найденному по сигнатуре, потому
+// что он точнее: сигнатура ZIP не отличает JAR от архива.
+func resolveContentType(policy, fileName, declared string, prefix []byte) (string, error) {
+	declared = declaredContentType(declared)
+	byExtension := extensionContentType(fileName)
+	sniffed := sniff.DetectContentType(prefix)
+
+	switch policy {
+	case config.ContentTypePolicyExtension:
+		return firstNonEmpty(byExtension, declared, sniffed), nil
+	case config.ContentTypePolicyDeclared:
+		return firstNonEmpty(declared, byExtension, sniffed), nil
+	}
+
+	candidates := make([]string, 0, 2)
+	for _, candidate := range []string{declared, byExtension} {
+		if candidate != "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if policy == config.ContentTypePolicyStrict && sniffed != sniff.UnknownContentType {
+		for _, candidate := range candidates {
+			if !sniff.Compatible(sniffed, candidate) {
+				return "", fmt.Errorf("%w: заявлен %s, по содержимому %s", ErrContentTypeMismatch, candidate, sniffed)
+			}
+		}
+	}
+
+	if !sniff.IsSpecific(sniffed) {
+		return firstNonEmpty(declared, byExtension, sniffed), nil
+	}
+	for _, candidate := range candidates {
+		if sniff.Compatible(sniffed, candidate) {
+			return candidate, nil
+		}
+	}
+
+	if len(candidates) > 0 {
+		slog.Warn("Тип содержимого не совпадает с заявленным", "file_name", fileName, "declared", candidates, "sniffed", sniffed)
+	}
+	return sniffed, nil
+}
+
+// declaredContentType отбрасывает типы, которые клиенты ставят
+// по умолчанию и которые ничего не говорят о файле
+func declaredContentType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		slog.Warn("Некорректный заявленный Content-Type", "content_type", contentType, "error", err)
+		return ""
+	}
+
+	switch mediaType {
+	case sniff.UnknownContentType, "application/x-www-form-urlencoded", "multipart/form-data":
+		return ""
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
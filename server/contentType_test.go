@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"s3_multiclient/config"
+	"testing"
+)
+
+var (
+	pngPrefix  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	textPrefix = []byte(`{"name": "value"}`)
+)
+
+func TestResolveContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		fileName string
+		declared string
+		prefix   []byte
+		want     string
+		wantErr  error
+	}{
+		{"sniff agrees", config.ContentTypePolicySniff, "image.png", "image/png", pngPrefix, "image/png", nil},
+		{"sniff overrides declared", config.ContentTypePolicySniff, "image.png", "image/gif", pngPrefix, "image/png", nil},
+		{"sniff overrides extension", config.ContentTypePolicySniff, "photo.jpg", "", pngPrefix, "image/png", nil},
+		{"sniff prefers compatible alias", config.ContentTypePolicySniff, "image", "image/x-png", pngPrefix, "image/x-png", nil},
+		{"sniff text keeps extension", config.ContentTypePolicySniff, "data.json", "", textPrefix, "application/json", nil},
+		{"sniff ignores octet-stream", config.ContentTypePolicySniff, defaultUploadFileName, "application/octet-stream", pngPrefix, "image/png", nil},
+		{"sniff without hints", config.ContentTypePolicySniff, defaultUploadFileName, "", textPrefix, "text/plain; charset=utf-8", nil},
+
+		{"declared wins", config.ContentTypePolicyDeclared, "photo.jpg", "image/gif", pngPrefix, "image/gif", nil},
+		{"declared falls back to extension", config.ContentTypePolicyDeclared, "photo.jpg", "", pngPrefix, "image/jpeg", nil},
+		{"declared falls back to sniffing", config.ContentTypePolicyDeclared, defaultUploadFileName, "", pngPrefix, "image/png", nil},
+
+		{"extension wins", config.ContentTypePolicyExtension, "photo.jpg", "image/gif", pngPrefix, "image/jpeg", nil},
+		{"extension falls back to declared", config.ContentTypePolicyExtension, defaultUploadFileName, "image/gif", pngPrefix, "image/gif", nil},
+		{"extension falls back to sniffing", config.ContentTypePolicyExtension, defaultUploadFileName, "", pngPrefix, "image/png", nil},
+
+		{"strict agrees", config.ContentTypePolicyStrict, "image.png", "image/png", pngPrefix, "image/png", nil},
+		{"strict rejects declared", config.ContentTypePolicyStrict, "image.png", "image/gif", pngPrefix, "", ErrContentTypeMismatch},
+		{"strict rejects extension", config.ContentTypePolicyStrict, "photo.jpg", "", pngPrefix, "", ErrContentTypeMismatch},
+		{"strict accepts text", config.ContentTypePolicyStrict, "data.json", "application/json", textPrefix, "application/json", nil},
+		{"strict skips unknown content", config.ContentTypePolicyStrict, "photo.jpg", "", []byte{0x00, 0x01, 0x02}, "image/jpeg", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveContentType(tt.policy, tt.fileName, tt.declared, tt.prefix)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("resolveContentType() = %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const versionIDQueryParam = "version_id"
+
+type DeleteRequestMetadata struct {
+	ID        string
+	Key       string
+	VersionID string
+}
+
+func (s *Server) Delete(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на удаление")
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getDeleteRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.Delete(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,13 +3,25 @@ package server
 import (
 	"log/slog"
 	"net/http"
-
-	"github.com/go-chi/chi"
+	"time"
 )
 
 type DownloadRequestMetadata struct {
-	ID    string
-	CRC32 uint32
+	ID     string
+	Key    string
+	Member *MemberSelector
+	Range  *ByteRange
+
+	Conditions RequestConditions
+	Password   ArchivePassword
+}
+
+// RequestedRange возвращает диапазон из Range с учетом If-Range
+func (d *DownloadRequestMetadata) RequestedRange(etag string, lastModified time.Time) *ByteRange {
+	if d.Range == nil || !d.Conditions.RangeApplies(etag, lastModified) {
+		return nil
+	}
+	return d.Range
 }
 
 func (s *Server) Download(w http.ResponseWriter, r *http.Request) {
@@ -21,16 +33,22 @@ func (s *Server) Download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsedData := chi.URLParam(r, "object_id")
-	downloadData, err := getIDandCRC(parsedData)
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	downloadData, err := getDownloadRequestData(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
-		slog.Error("Не удалось извлечь object_id и crc32", "error", err)
 		return
 	}
 
-	if err := s.loadManager.Download(w, s.ctx, downloadData); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	downloadData.Range = parseRangeHeader(r.Header.Get("Range"))
+
+	if err := loadManager.Download(w, s.ctx, downloadData); err != nil {
+		writeError(w, err)
 		return
 	}
 }
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// ArchiveEntry — запись центрального каталога архива
+type ArchiveEntry struct {
+	Index            int       `json:"index"`
+	Name             string    `json:"name"`
+	CRC32            uint32    `json:"crc32"`
+	CompressedSize   int64     `json:"compressed_size"`
+	UncompressedSize int64     `json:"uncompressed_size"`
+	Modified         time.Time `json:"modified"`
+	IsDir            bool      `json:"is_dir"`
+	Encrypted        bool      `json:"encrypted,omitempty"`
+}
+
+type archiveEntriesResponse struct {
+	ID      string          `json:"id"`
+	Entries []*ArchiveEntry `json:"entries"`
+}
+
+func (s *Server) Entries(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на просмотр архива")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getDownloadRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := loadManager.ListEntries(s.ctx, data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := &archiveEntriesResponse{ID: data.ID, Entries: entries}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Ошибки, по которым обработчики выбирают HTTP-статус ответа.
+// Нижележащие слои оборачивают их через fmt.Errorf("...: %w", ...).
+var (
+	ErrStorageNotFound = errors.New("хранилище не найдено")
+	ErrObjectNotFound  = errors.New("объект не найден")
+	ErrObjectExists    = errors.New("объект уже существует")
+	ErrReservedKey     = errors.New("ключ находится в служебной области хранилища")
+	ErrNotArchive      = errors.New("объект не является архивом")
+	ErrArchiveLimit    = errors.New("превышен лимит обработки архивов")
+	ErrUnsafeEntryPath = errors.New("недопустимый путь элемента архива")
+	ErrInvalidParts    = errors.New("части multipart-загрузки не совпадают с загруженными")
+
+	ErrContentTypeMismatch = errors.New("тип содержимого не совпадает с заявленным")
+
+	ErrPasswordRequired      = errors.New("элемент архива зашифрован, нужен пароль в заголовке " + ArchivePasswordHeader)
+	ErrWrongPassword         = errors.New("неверный пароль от архива")
+	ErrUnsupportedEncryption = errors.New("способ шифрования архива не поддерживается")
+
+	ErrRangeNotSatisfiable = errors.New("запрошенный диапазон недоступен")
+	ErrNotModified         = errors.New("объект не изменялся")
+	ErrObjectChanged       = errors.New("объект изменился во время обработки запроса")
+
+	ErrUploadOffsetMismatch = errors.New("Upload-Offset не совпадает с размером полученных данных")
+	ErrUploadTooLarge       = errors.New("данных больше, чем объявлено в Upload-Length")
+	ErrUploadLocked         = errors.New("загрузка уже обрабатывается другим запросом")
+
+	// ErrStreamInterrupted означает, что заголовки и часть тела уже отправлены.
+	// Исправить статус нельзя, поэтому соединение обрывается, чтобы клиент
+	// не принял усеченный ответ за полный.
+	ErrStreamInterrupted = errors.New("передача данных прервана")
+)
+
+func errorStatus(err error) int {
+	if _, ok := asAmbiguousMember(err); ok {
+		return http.StatusConflict
+	}
+
+	switch {
+	case errors.Is(err, ErrStorageNotFound), errors.Is(err, ErrObjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrObjectExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrReservedKey), errors.Is(err, ErrNotArchive), errors.Is(err, ErrUnsafeEntryPath),
+		errors.Is(err, ErrInvalidParts):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrPasswordRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrArchiveLimit), errors.Is(err, ErrWrongPassword), errors.Is(err, ErrUnsupportedEncryption):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrContentTypeMismatch):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrUploadOffsetMismatch):
+		return http.StatusConflict
+	case errors.Is(err, ErrUploadTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUploadLocked):
+		return http.StatusLocked
+	case errors.Is(err, ErrObjectChanged):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrRangeNotSatisfiable):
+		return http.StatusRequestedRangeNotSatisfiable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotModified) {
+		// Ответ 304 не содержит тела
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if errors.Is(err, ErrStreamInterrupted) {
+		slog.Error("Ответ прерван после начала передачи", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if ambiguous, ok := asAmbiguousMember(err); ok {
+		sendAmbiguousMemberResponse(w, ambiguous)
+		return
+	}
+	setErrorHeaders(w, err)
+	http.Error(w, err.Error(), errorStatus(err))
+}
+
+// writeRequestError отвечает на ошибку разбора запроса: сигнальные ошибки
+// получают свой статус, остальные означают некорректный запрос
+func writeRequestError(w http.ResponseWriter, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		status = http.StatusBadRequest
+	}
+	setErrorHeaders(w, err)
+	http.Error(w, err.Error(), status)
+}
+
+// setErrorHeaders добавляет заголовки, которых требует статус ошибки:
+// ответ 401 обязан содержать WWW-Authenticate
+func setErrorHeaders(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrPasswordRequired) {
+		w.Header().Set("WWW-Authenticate", archivePasswordChallenge)
+	}
+}
+
+type ambiguousMemberResponse struct {
+	Error      string          `json:"error"`
+	Candidates []*ArchiveEntry `json:"candidates"`
+}
+
+func sendAmbiguousMemberResponse(w http.ResponseWriter, ambiguous *AmbiguousMemberError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+
+	response := &ambiguousMemberResponse{Error: ambiguous.Error(), Candidates: ambiguous.Candidates}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
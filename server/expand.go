@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+const successfulExpandStatus = "expanded"
+
+// ExpandedObject — запись манифеста распакованного архива: путь элемента
+// в архиве и объект, в который он сохранен
+type ExpandedObject struct {
+	Path   string `json:"path"`
+	ID     string `json:"id"`
+	Key    string `json:"-"`
+	CRC32  uint32 `json:"crc32"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+type expandResponse struct {
+	Status  string            `json:"status"`
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Objects []*ExpandedObject `json:"objects"`
+}
+
+func sendExpandResponse(w http.ResponseWriter, data *UploadRequestMetadata) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	response := &expandResponse{
+		Status:  successfulExpandStatus,
+		ID:      data.ID,
+		Name:    data.FileName,
+		Objects: data.Manifest,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
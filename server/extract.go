@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const successfulExtractStatus = "extracted"
+
+// ExtractRequestMetadata описывает копирование элемента архива в отдельный
+// объект. Source задает архив и цепочку селекторов, ID и Key — новый объект.
+// FileName, ContentType и Size заполняются слоем хранилища.
+type ExtractRequestMetadata struct {
+	Source *DownloadRequestMetadata
+
+	ID          string
+	Key         string
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+func (s *Server) Extract(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на извлечение элемента архива")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getExtractRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.Extract(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendObjectResponse(w, http.StatusCreated, &objectResponse{
+		Status: successfulExtractStatus,
+		ID:     data.ID,
+		Name:   data.FileName,
+		Type:   data.ContentType,
+		Size:   getSizeMB(data.Size),
+		Bytes:  data.Size,
+	})
+}
+
+// getExtractRequestData читает архив и селектор из object_id, а идентификатор
+// нового объекта — из параметра target_id. Без target_id он генерируется.
+// Новый объект создается в том же relative_path, что и архив.
+func getExtractRequestData(r *http.Request) (*ExtractRequestMetadata, error) {
+	source, err := getDownloadRequestData(r)
+	if err != nil {
+		return nil, err
+	}
+	if source.Member == nil {
+		return nil, fmt.Errorf("необходим селектор элемента архива в object_id")
+	}
+
+	targetID := strings.TrimSpace(r.URL.Query().Get("target_id"))
+	if targetID == "" {
+		targetID = uuid.NewString()
+	}
+
+	key, err := parseObjectKey(r, targetID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ нового объекта", "error", err)
+		return nil, err
+	}
+
+	return &ExtractRequestMetadata{Source: source, ID: targetID, Key: key}, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Форматы архивов, которые сервис собирает на лету
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+)
+
+// FolderRequestMetadata описывает выгрузку всех объектов под префиксом
+type FolderRequestMetadata struct {
+	Prefix string
+	Name   string
+	Format string
+}
+
+func (s *Server) DownloadFolder(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на скачивание папки")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getFolderRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.DownloadFolder(w, s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+}
+
+func getFolderRequestData(r *http.Request) (*FolderRequestMetadata, error) {
+	folder, err := parseRelativePath(r)
+	if err != nil {
+		slog.Error("Не удалось разобрать relative_path", "error", err)
+		return nil, err
+	}
+
+	if prefix := r.URL.Query().Get("prefix"); strings.Trim(prefix, keySeparator) != "" {
+		prefix, err = normalizeRelativePath(prefix)
+		if err != nil {
+			return nil, err
+		}
+		folder = folder + keySeparator + prefix
+	}
+
+	format, err := parseArchiveFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &FolderRequestMetadata{
+		Prefix: folder + keySeparator,
+		Name:   path.Base(folder) + "." + format,
+		Format: format,
+	}
+	return data, nil
+}
+
+// parseArchiveFormat выбирает формат по параметру format, а при его
+// отсутствии — по заголовку Accept. По умолчанию используется ZIP.
+func parseArchiveFormat(r *http.Request) (string, error) {
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case "":
+	case ArchiveFormatZip:
+		return ArchiveFormatZip, nil
+	case ArchiveFormatTarGz, "tgz":
+		return ArchiveFormatTarGz, nil
+	default:
+		return "", fmt.Errorf("неподдерживаемый формат архива: %s", format)
+	}
+
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	for _, mediaType := range []string{"application/gzip", "application/x-gzip", "application/x-gtar", "application/x-tar"} {
+		if strings.Contains(accept, mediaType) {
+			return ArchiveFormatTarGz, nil
+		}
+	}
+	return ArchiveFormatZip, nil
+}
+
+// ArchiveContentType — Content-Type ответа для формата архива
+func ArchiveContentType(format string) string {
+	if format == ArchiveFormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemberSelector выбирает элемент архива. Заданные критерии объединяются по «И».
+//
+// В сегменте {object_id} селектор указывается после ';':
+//
+//	id;123456          — CRC32 в десятичном виде (прежний формат)
+//	id;crc32=123456    — то же самое
+//	id;crc32=123456,size=42
+//	id;path=dir%2Ffile.csv
+//	id;index=3         — порядковый номер в каталоге архива, начиная с 0
+//
+// Несколько селекторов подряд задают путь через вложенные архивы:
+//
+//	id;path=inner.zip;path=data%2Ffile.csv
+//
+// Символы ',' и ';' внутри path не поддерживаются.
+type MemberSelector struct {
+	Path  string
+	Index *int
+	CRC32 *uint32
+	Size  *int64
+
+	// Nested выбирает элемент внутри архива, найденного этим селектором
+	Nested *MemberSelector
+}
+
+// Depth — число уровней вложенности ниже хранимого объекта
+func (ms *MemberSelector) Depth() int {
+	depth := 0
+	for nested := ms.Nested; nested != nil; nested = nested.Nested {
+		depth++
+	}
+	return depth
+}
+
+func (ms *MemberSelector) String() string {
+	var parts []string
+	if ms.Path != "" {
+		parts = append(parts, "path="+ms.Path)
+	}
+	if ms.Index != nil {
+		parts = append(parts, "index="+strconv.Itoa(*ms.Index))
+	}
+	if ms.CRC32 != nil {
+		parts = append(parts, "crc32="+strconv.FormatUint(uint64(*ms.CRC32), 10))
+	}
+	if ms.Size != nil {
+		parts = append(parts, "size="+strconv.FormatInt(*ms.Size, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// AmbiguousMemberError возвращается, когда селектору соответствует
+// несколько элементов архива
+type AmbiguousMemberError struct {
+	Selector   string
+	Candidates []*ArchiveEntry
+}
+
+func (e *AmbiguousMemberError) Error() string {
+	return fmt.Sprintf("селектору %q соответствует несколько элементов архива: %d", e.Selector, len(e.Candidates))
+}
+
+func parseMemberSelector(spec string) (*MemberSelector, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("пустой селектор элемента архива")
+	}
+
+	selector := &MemberSelector{}
+
+	if !strings.Contains(spec, "=") {
+		crc32, err := parseCRC32(spec)
+		if err != nil {
+			return nil, err
+		}
+		selector.CRC32 = &crc32
+		return selector, nil
+	}
+
+	for pair := range strings.SplitSeq(spec, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("ожидается key=value в селекторе, получено %q", pair)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		switch name {
+		case "path":
+			if value == "" {
+				return nil, fmt.Errorf("пустой path в селекторе")
+			}
+			selector.Path = strings.TrimPrefix(value, "/")
+		case "index":
+			index, err := strconv.Atoi(value)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("некорректный index в селекторе: %q", value)
+			}
+			selector.Index = &index
+		case "crc32":
+			crc32, err := parseCRC32(value)
+			if err != nil {
+				return nil, err
+			}
+			selector.CRC32 = &crc32
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("некорректный size в селекторе: %q", value)
+			}
+			selector.Size = &size
+		default:
+			return nil, fmt.Errorf("неизвестный ключ селектора %q", name)
+		}
+	}
+
+	if selector.Path == "" && selector.Index == nil && selector.CRC32 == nil {
+		return nil, fmt.Errorf("селектор должен содержать path, index или crc32")
+	}
+	return selector, nil
+}
+
+func parseCRC32(value string) (uint32, error) {
+	crcValue, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse CRC value: %v", err)
+	}
+	return uint32(crcValue), nil
+}
+
+// Select находит в каталоге архива единственный элемент, подходящий под селектор.
+// Если подходящих несколько, возвращается *AmbiguousMemberError со списком кандидатов.
+func (ms *MemberSelector) Select(entries []*ArchiveEntry) (*ArchiveEntry, error) {
+	if ms.Index != nil {
+		if *ms.Index >= len(entries) {
+			return nil, fmt.Errorf("%w: элемент с index=%d отсутствует в архиве", ErrObjectNotFound, *ms.Index)
+		}
+		entries = entries[*ms.Index : *ms.Index+1]
+	}
+
+	var candidates []*ArchiveEntry
+	for _, entry := range entries {
+		if ms.Matches(entry) {
+			candidates = append(candidates, entry)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w: в архиве нет элемента %s", ErrObjectNotFound, ms)
+	case 1:
+		return candidates[0], nil
+	default:
+		return nil, &AmbiguousMemberError{Selector: ms.String(), Candidates: candidates}
+	}
+}
+
+// Matches проверяет критерии path, crc32 и size. Каталоги под CRC32 не подходят.
+func (ms *MemberSelector) Matches(entry *ArchiveEntry) bool {
+	if ms.Path != "" && entry.Name != ms.Path {
+		return false
+	}
+	if ms.CRC32 != nil && (entry.IsDir || entry.CRC32 != *ms.CRC32) {
+		return false
+	}
+	if ms.Size != nil && entry.UncompressedSize != *ms.Size {
+		return false
+	}
+	return true
+}
+
+func asAmbiguousMember(err error) (*AmbiguousMemberError, bool) {
+	var ambiguous *AmbiguousMemberError
+	ok := errors.As(err, &ambiguous)
+	return ambiguous, ok
+}
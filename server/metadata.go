@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ObjectMetadata — сведения об объекте или о выбранном элементе архива
+// без передачи содержимого
+type ObjectMetadata struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	Size         int64     `json:"size"`
+	UploadedAt   string    `json:"uploaded_at,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified time.Time `json:"last_modified"`
+	MemberPath   string    `json:"member_path,omitempty"`
+	CRC32        *uint32   `json:"crc32,omitempty"`
+}
+
+func (s *Server) Head(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки HEAD-запроса")
+
+	if r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	metadata, data, ok := s.statObject(w, r)
+	if !ok {
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", metadata.Type)
+	header.Set("Content-Length", strconv.FormatInt(metadata.Size, 10))
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, metadata.Name))
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("X-Original-Name", url.PathEscape(metadata.Name))
+	if metadata.UploadedAt != "" {
+		header.Set("X-Uploaded-At", metadata.UploadedAt)
+	}
+	setValidatorHeaders(header, metadata)
+
+	if data.Conditions.NotModified(metadata.ETag, metadata.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) Metadata(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса метаданных")
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	metadata, data, ok := s.statObject(w, r)
+	if !ok {
+		return
+	}
+
+	setValidatorHeaders(w.Header(), metadata)
+	if data.Conditions.NotModified(metadata.ETag, metadata.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(metadata); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
+
+func (s *Server) statObject(w http.ResponseWriter, r *http.Request) (*ObjectMetadata, *DownloadRequestMetadata, bool) {
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return nil, nil, false
+	}
+
+	data, err := getDownloadRequestData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	metadata, err := loadManager.Stat(s.ctx, data)
+	if err != nil {
+		writeError(w, err)
+		return nil, nil, false
+	}
+
+	return metadata, data, true
+}
+
+func setValidatorHeaders(header http.Header, metadata *ObjectMetadata) {
+	if metadata.ETag != "" {
+		header.Set("ETag", metadata.ETag)
+	}
+	if !metadata.LastModified.IsZero() {
+		header.Set("Last-Modified", metadata.LastModified.UTC().Format(http.TimeFormat))
+	}
+}
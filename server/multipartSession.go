@@ -0,0 +1,278 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const (
+	successfulInitiateStatus = "initiated"
+
+	// Ограничения S3 на multipart-загрузку
+	maxMultipartParts = 10000
+	maxPartSize       = 5 * 1024 * 1024 * 1024
+)
+
+// MultipartUploadMetadata — сессия multipart-загрузки объекта ID. UploadID
+// выдается хранилищем при создании сессии. PartNumber и PartSize задают
+// загружаемую часть, Parts — манифест при завершении или список частей,
+// который заполняет хранилище. Size заполняется после завершения.
+type MultipartUploadMetadata struct {
+	ID          string
+	Key         string
+	UploadID    string
+	FileName    string
+	ContentType string
+	Size        int64
+
+	PartNumber int
+	PartSize   int64
+	Parts      []*UploadedPart
+}
+
+// UploadedPart — часть multipart-загрузки
+type UploadedPart struct {
+	PartNumber   int       `json:"part_number"`
+	ETag         string    `json:"etag"`
+	Size         int64     `json:"size,omitempty"`
+	LastModified time.Time `json:"last_modified,omitzero"`
+}
+
+type multipartSessionResponse struct {
+	Status   string          `json:"status,omitempty"`
+	ID       string          `json:"id"`
+	UploadID string          `json:"upload_id"`
+	Parts    []*UploadedPart `json:"parts,omitempty"`
+}
+
+type completeMultipartRequest struct {
+	Parts []*UploadedPart `json:"parts"`
+}
+
+// InitiateMultipartUpload создает сессию. Имя файла берется
+// из Content-Disposition, как при обычной загрузке.
+func (s *Server) InitiateMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на создание multipart-загрузки")
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getMultipartSessionData(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data.FileName, err = parseFileNameFromDisposition(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data.ContentType = getContentType(data.FileName)
+
+	if err := loadManager.InitiateMultipartUpload(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendMultipartResponse(w, http.StatusCreated, &multipartSessionResponse{
+		Status:   successfulInitiateStatus,
+		ID:       data.ID,
+		UploadID: data.UploadID,
+	})
+}
+
+// UploadPart принимает одну часть. Размер части должен быть известен
+// заранее, поэтому запрос без Content-Length отклоняется.
+func (s *Server) UploadPart(w http.ResponseWriter, r *http.Request) {
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getMultipartSessionData(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data.PartNumber, err = strconv.Atoi(chi.URLParam(r, "part_number"))
+	if err != nil || data.PartNumber < 1 || data.PartNumber > maxMultipartParts {
+		http.Error(w, fmt.Sprintf("номер части должен быть от 1 до %d", maxMultipartParts), http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength < 0 {
+		http.Error(w, "необходим Content-Length", http.StatusLengthRequired)
+		return
+	}
+	if r.ContentLength > maxPartSize {
+		http.Error(w, fmt.Sprintf("часть больше %d байт", int64(maxPartSize)), http.StatusRequestEntityTooLarge)
+		return
+	}
+	data.PartSize = r.ContentLength
+
+	if err := loadManager.UploadPart(r.Body, s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendMultipartResponse(w, http.StatusOK, data.Parts[0])
+}
+
+func (s *Server) ListParts(w http.ResponseWriter, r *http.Request) {
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getMultipartSessionData(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.ListParts(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendMultipartResponse(w, http.StatusOK, &multipartSessionResponse{
+		ID:       data.ID,
+		UploadID: data.UploadID,
+		Parts:    data.Parts,
+	})
+}
+
+// CompleteMultipartUpload собирает объект из частей манифеста. Части
+// перечисляются по возрастанию номера, как требует S3.
+func (s *Server) CompleteMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на завершение multipart-загрузки")
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getMultipartSessionData(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data.Parts, err = parsePartManifest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.CompleteMultipartUpload(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendObjectResponse(w, http.StatusCreated, &objectResponse{
+		Status: successfulUploadStatus,
+		ID:     data.ID,
+		Name:   data.FileName,
+		Type:   data.ContentType,
+		Size:   getSizeMB(data.Size),
+		Bytes:  data.Size,
+	})
+}
+
+func (s *Server) AbortMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на отмену multipart-загрузки")
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getMultipartSessionData(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.AbortMultipartUpload(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func getMultipartSessionData(r *http.Request, withUploadID bool) (*MultipartUploadMetadata, error) {
+	objectID, err := parseObjectID(r)
+	if err != nil {
+		slog.Error("Не удалось извлечь object_id", "error", err)
+		return nil, err
+	}
+
+	key, err := parseObjectKey(r, objectID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ объекта", "error", err)
+		return nil, err
+	}
+
+	data := &MultipartUploadMetadata{ID: objectID, Key: key}
+	if withUploadID {
+		data.UploadID, err = urlParam(r, "upload_id")
+		if err != nil {
+			return nil, err
+		}
+		if data.UploadID = strings.TrimSpace(data.UploadID); data.UploadID == "" {
+			return nil, fmt.Errorf("необходим upload_id")
+		}
+	}
+	return data, nil
+}
+
+func parsePartManifest(r *http.Request) ([]*UploadedPart, error) {
+	var request completeMultipartRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Ошибка разбора манифеста частей", "error", err)
+		return nil, fmt.Errorf("некорректное тело запроса: %w", err)
+	}
+
+	if len(request.Parts) == 0 {
+		return nil, fmt.Errorf("список parts пуст")
+	}
+	if len(request.Parts) > maxMultipartParts {
+		return nil, fmt.Errorf("в манифесте не может быть больше %d частей", maxMultipartParts)
+	}
+
+	previous := 0
+	for _, part := range request.Parts {
+		if part == nil || part.ETag == "" {
+			return nil, fmt.Errorf("для каждой части нужны part_number и etag")
+		}
+		if part.PartNumber <= previous || part.PartNumber > maxMultipartParts {
+			return nil, fmt.Errorf("номера частей должны возрастать и быть от 1 до %d", maxMultipartParts)
+		}
+		previous = part.PartNumber
+	}
+	return request.Parts, nil
+}
+
+func sendMultipartResponse(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
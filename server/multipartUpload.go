@@ -0,0 +1,293 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"s3_multiclient/file/sniff"
+	"strconv"
+	"strings"
+)
+
+const (
+	failedUploadStatus = "error"
+
+	maxMultipartFiles = 100
+	// maxFormMetadataSize — общий размер метаданных объекта в S3
+	maxFormMetadataSize = 2 * 1024
+	// serviceMetadataPrefix — префикс метаданных, которые пишет сам сервис
+	serviceMetadataPrefix = "X-"
+)
+
+// standardHeaderFields — поля, которые S3 принимает как заголовки объекта,
+// а не как пользовательские метаданные. minio-go отказывается записывать
+// их в UserMetadata.
+var standardHeaderFields = map[string]bool{
+	"Content-Type":        true,
+	"Content-Encoding":    true,
+	"Content-Disposition": true,
+	"Content-Language":    true,
+	"Cache-Control":       true,
+	"Expires":             true,
+}
+
+// uploadedFile — результат загрузки одного файла из формы
+type uploadedFile struct {
+	*objectResponse
+	Objects []*ExpandedObject `json:"objects,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type multipartUploadResponse struct {
+	Files []*uploadedFile `json:"files"`
+	Error string          `json:"error,omitempty"`
+}
+
+func isMultipartForm(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// uploadMultipart читает форму потоком: каждый файл сразу передается
+// в хранилище, не накапливаясь в памяти. Текстовые поля становятся
+// метаданными файлов, которые идут в форме после них. Первый файл получает
+// object_id из пути, следующие — object_id_2, object_id_3 и так далее;
+// сгенерированные ID не перезаписывают существующие объекты (409).
+// Файлы, загруженные до ошибки, остаются в хранилище: в ответе с кодом
+// ошибки перечисляются они и файл со статусом error, на котором загрузка
+// остановилась.
+func (s *Server) uploadMultipart(w http.ResponseWriter, r *http.Request, loadManager LoadManager) {
+	objectID, err := parseObjectID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		slog.Error("Ошибка разбора multipart/form-data", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	form := &formMetadata{fields: make(map[string]string)}
+	response := &multipartUploadResponse{}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			slog.Error("Ошибка чтения части формы", "error", err)
+			http.Error(w, fmt.Sprintf("некорректное тело запроса: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if part.FileName() == "" {
+			if err := form.add(part); err != nil {
+				sendMultipartFailure(w, response, nil, err, http.StatusBadRequest)
+				return
+			}
+			continue
+		}
+
+		if len(response.Files) == maxMultipartFiles {
+			err := fmt.Errorf("за один запрос можно загрузить не более %d файлов", maxMultipartFiles)
+			sendMultipartFailure(w, response, nil, err, http.StatusBadRequest)
+			return
+		}
+
+		fileID := objectID
+		if len(response.Files) > 0 {
+			fileID = objectID + "_" + strconv.Itoa(len(response.Files)+1)
+		}
+
+		data, body, err := getMultipartUploadData(r, part, relativePath, fileID, form, s.upload.ContentTypePolicy)
+		if err != nil {
+			status := errorStatus(err)
+			if status == http.StatusInternalServerError {
+				status = http.StatusBadRequest
+			}
+			failed := &UploadRequestMetadata{ID: fileID, FileName: sanitizeFileName(part.FileName())}
+			sendMultipartFailure(w, response, failed, err, status)
+			return
+		}
+		data.CreateOnly = fileID != objectID
+
+		if err := loadManager.Upload(body, s.ctx, data); err != nil {
+			sendMultipartFailure(w, response, data, err, errorStatus(err))
+			return
+		}
+		response.Files = append(response.Files, newUploadedFile(data))
+	}
+
+	if len(response.Files) == 0 {
+		http.Error(w, "в форме нет ни одного файла", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
+
+func getMultipartUploadData(r *http.Request, part *multipart.Part, relativePath, fileID string, form *formMetadata, contentTypePolicy string) (*UploadRequestMetadata, io.Reader, error) {
+	key, err := buildObjectKey(relativePath, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileName := sanitizeFileName(part.FileName())
+	if fileName == "" {
+		fileName = defaultUploadFileName
+	}
+
+	prefix, body, err := peekReader(part, sniff.PrefixSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Браузер указывает тип файла в заголовке части, а для неизвестных
+	// типов ставит application/octet-stream
+	contentType, err := resolveContentType(contentTypePolicy, fileName, part.Header.Get("Content-Type"), prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data := &UploadRequestMetadata{
+		ID:          fileID,
+		Key:         key,
+		FileName:    fileName,
+		ContentType: contentType,
+		// Размер части формы заранее неизвестен
+		Size:     -1,
+		Metadata: form.snapshot(),
+	}
+	if err := setUploadMode(r, data, prefix); err != nil {
+		return nil, nil, err
+	}
+
+	slog.Info("Начало загрузки файла из формы", "object_id", fileID, "file_name", fileName, "content_type", contentType)
+	return data, body, nil
+}
+
+// sendMultipartFailure отвечает на ошибку посреди формы. Пока ни один файл
+// не загружен, ответ — обычный текст ошибки. Иначе клиент получает список
+// загруженных файлов, чтобы повторить только оставшиеся.
+func sendMultipartFailure(w http.ResponseWriter, response *multipartUploadResponse, failed *UploadRequestMetadata, err error, status int) {
+	slog.Error("Загрузка файлов из формы прервана", "files_uploaded", len(response.Files), "error", err)
+
+	setErrorHeaders(w, err)
+	if len(response.Files) == 0 {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	response.Error = err.Error()
+	if failed != nil {
+		response.Files = append(response.Files, &uploadedFile{
+			objectResponse: &objectResponse{
+				Status: failedUploadStatus,
+				ID:     failed.ID,
+				Name:   failed.FileName,
+				Type:   failed.ContentType,
+			},
+			Error: err.Error(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
+
+func newUploadedFile(data *UploadRequestMetadata) *uploadedFile {
+	file := &uploadedFile{
+		objectResponse: &objectResponse{
+			Status: successfulUploadStatus,
+			ID:     data.ID,
+			Name:   data.FileName,
+			Type:   data.ContentType,
+			Size:   getSizeMB(data.Size),
+			Bytes:  data.Size,
+		},
+	}
+	if data.Expand {
+		file.Status = successfulExpandStatus
+		file.Objects = data.Manifest
+	}
+	return file
+}
+
+// formMetadata собирает текстовые поля формы. Имена полей приводятся
+// к виду заголовка, имена с префиксом X- зарезервированы за сервисом,
+// стандартные заголовки объекта в метаданные не попадают.
+type formMetadata struct {
+	fields map[string]string
+	size   int
+}
+
+func (fm *formMetadata) add(part *multipart.Part) error {
+	name := part.FormName()
+	if name == "" || strings.IndexFunc(name, func(c rune) bool {
+		return !(c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z')
+	}) >= 0 {
+		return fmt.Errorf("недопустимое имя поля формы %q", name)
+	}
+
+	name = textproto.CanonicalMIMEHeaderKey(name)
+	if strings.HasPrefix(name, serviceMetadataPrefix) {
+		return fmt.Errorf("имена полей с префиксом %s зарезервированы: %q", serviceMetadataPrefix, name)
+	}
+	if standardHeaderFields[name] {
+		return fmt.Errorf("поле формы %q совпадает со стандартным заголовком объекта", name)
+	}
+
+	value, err := io.ReadAll(io.LimitReader(part, maxFormMetadataSize+1))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения поля формы %q: %w", name, err)
+	}
+	// Значение уходит в заголовок запроса к S3
+	if strings.ContainsFunc(string(value), func(c rune) bool {
+		return c < ' ' && c != '\t' || c == 0x7f
+	}) {
+		return fmt.Errorf("поле формы %q содержит управляющие символы", name)
+	}
+
+	if previous, ok := fm.fields[name]; ok {
+		fm.size -= len(name) + len(previous)
+	}
+	fm.size += len(name) + len(value)
+	if fm.size > maxFormMetadataSize {
+		return fmt.Errorf("метаданные из полей формы больше %d байт", maxFormMetadataSize)
+	}
+
+	fm.fields[name] = string(value)
+	return nil
+}
+
+func (fm *formMetadata) snapshot() map[string]string {
+	if len(fm.fields) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(fm.fields))
+	for name, value := range fm.fields {
+		fields[name] = value
+	}
+	return fields
+}
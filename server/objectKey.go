@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/go-chi/chi"
+)
+
+// Сегмент {relative_path} задает пространство имен объекта: ключ в бакете
+// строится как "<relative_path>/<object_id>". Вложенные каталоги передаются
+// в сегменте с экранированным слэшем, например "team-a%2Freports".
+
+const keySeparator = "/"
+
+// urlParam возвращает декодированный параметр пути. chi сопоставляет
+// маршрут по r.URL.RawPath, только если он задан, то есть в пути есть
+// экранирование вроде %2F. Иначе параметр уже декодирован, и повторное
+// декодирование испортило бы значения с '%'.
+func urlParam(r *http.Request, name string) (string, error) {
+	value := chi.URLParam(r, name)
+	if r.URL.RawPath == "" {
+		return value, nil
+	}
+
+	value, err := url.PathUnescape(value)
+	if err != nil {
+		return "", fmt.Errorf("некорректное экранирование параметра %s: %w", name, err)
+	}
+	return value, nil
+}
+
+func parseRelativePath(r *http.Request) (string, error) {
+	relativePath, err := urlParam(r, "relative_path")
+	if err != nil {
+		return "", err
+	}
+	return normalizeRelativePath(relativePath)
+}
+
+func normalizeRelativePath(relativePath string) (string, error) {
+	relativePath = strings.Trim(relativePath, keySeparator)
+	if relativePath == "" {
+		return "", fmt.Errorf("необходим relative_path")
+	}
+
+	for _, segment := range strings.Split(relativePath, keySeparator) {
+		if err := validateKeySegment(segment); err != nil {
+			return "", fmt.Errorf("некорректный relative_path %q: %w", relativePath, err)
+		}
+	}
+	return relativePath, nil
+}
+
+func validateKeySegment(segment string) error {
+	switch segment {
+	case "":
+		return fmt.Errorf("пустой сегмент пути")
+	case ".", "..":
+		return fmt.Errorf("недопустимый сегмент пути %q", segment)
+	}
+
+	for _, char := range segment {
+		if unicode.IsControl(char) || char == '\\' {
+			return fmt.Errorf("недопустимый символ %q в сегменте пути", char)
+		}
+	}
+	return nil
+}
+
+func buildObjectKey(relativePath, objectID string) (string, error) {
+	if strings.Contains(objectID, keySeparator) {
+		return "", fmt.Errorf("object_id не может содержать %q", keySeparator)
+	}
+	if err := validateKeySegment(objectID); err != nil {
+		return "", fmt.Errorf("некорректный object_id %q: %w", objectID, err)
+	}
+	return relativePath + keySeparator + objectID, nil
+}
+
+func parseObjectKey(r *http.Request, objectID string) (string, error) {
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		return "", err
+	}
+	return buildObjectKey(relativePath, objectID)
+}
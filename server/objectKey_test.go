@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestParseObjectKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"plain", "/reports/objects/file-1", "reports/file-1"},
+		{"escaped slash in relative_path", "/team-a%2Freports/objects/file-1", "team-a/reports/file-1"},
+		{"literal percent", "/reports/objects/50%25", "reports/50%"},
+		{"escaped percent sequence", "/reports/objects/a%2541", "reports/a%41"},
+		{"literal percent with escaped slash", "/team-a%2Freports/objects/50%25", "team-a/reports/50%"},
+		{"escaped space", "/reports/objects/my%20file", "reports/my file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				key string
+				err error
+			)
+			router := chi.NewRouter()
+			router.Get("/{relative_path}/objects/{object_id}", func(w http.ResponseWriter, r *http.Request) {
+				var objectID string
+				if objectID, err = parseObjectID(r); err == nil {
+					key, err = parseObjectKey(r, objectID)
+				}
+			})
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("маршрут не найден: %d", recorder.Code)
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if key != tt.want {
+				t.Fatalf("ключ %q, ожидался %q", key, tt.want)
+			}
+		})
+	}
+}
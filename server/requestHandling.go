@@ -1,16 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"mime"
 	"net/http"
 	"path/filepath"
+	"s3_multiclient/file/sniff"
 	"strconv"
 	"strings"
-
-	"github.com/go-chi/chi"
 )
 
 const (
@@ -18,7 +20,7 @@ const (
 	successfulUploadStatus = "uploaded"
 )
 
-func getIDandCRC(parsedData string) (handledData *DownloadRequestMetadata, err error) {
+func getIDandSelector(parsedData string) (handledData *DownloadRequestMetadata, err error) {
 	parsedData = strings.TrimSpace(parsedData)
 	if parsedData == "" {
 		err = fmt.Errorf("object identifier is required")
@@ -27,11 +29,6 @@ func getIDandCRC(parsedData string) (handledData *DownloadRequestMetadata, err e
 	}
 
 	parts := strings.Split(parsedData, ";")
-	if len(parts) > 2 {
-		err = fmt.Errorf("invalid format: expected 'objectID;crc32'")
-		slog.Error("Неверный формат идентификатора", "error", err, "parsed_info", parsedData)
-		return nil, err
-	}
 
 	objectID := strings.TrimSpace(parts[0])
 	if objectID == "" {
@@ -40,66 +37,282 @@ func getIDandCRC(parsedData string) (handledData *DownloadRequestMetadata, err e
 		return nil, err
 	}
 
-	var crc32 uint32
-	if len(parts) == 2 {
-		crcStr := strings.TrimSpace(parts[1])
-		crcValue, parseErr := strconv.ParseUint(crcStr, 10, 32)
-		if parseErr != nil {
-			err = fmt.Errorf("failed to parse CRC value: %v", parseErr)
-			slog.Error("Ошибка разбора значения CRC", "error", err, "crc32", crcStr)
+	handledData = &DownloadRequestMetadata{ID: objectID}
+
+	var last *MemberSelector
+	for _, spec := range parts[1:] {
+		selector, err := parseMemberSelector(spec)
+		if err != nil {
+			slog.Error("Ошибка разбора селектора элемента архива", "error", err, "selector", spec)
 			return nil, err
 		}
-		crc32 = uint32(crcValue)
-	}
 
-	handledData = &DownloadRequestMetadata{ID: objectID, CRC32: crc32}
+		if last == nil {
+			handledData.Member = selector
+		} else {
+			last.Nested = selector
+		}
+		last = selector
+	}
 
 	return handledData, nil
 }
 
+func getDownloadRequestData(r *http.Request) (*DownloadRequestMetadata, error) {
+	parsedData, err := urlParam(r, "object_id")
+	if err != nil {
+		return nil, err
+	}
+
+	downloadData, err := getIDandSelector(parsedData)
+	if err != nil {
+		slog.Error("Не удалось извлечь object_id и селектор", "error", err)
+		return nil, err
+	}
+
+	downloadData.Key, err = parseObjectKey(r, downloadData.ID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ объекта", "error", err)
+		return nil, err
+	}
+
+	downloadData.Conditions = parseRequestConditions(r)
+	downloadData.Password = parseArchivePassword(r)
+
+	return downloadData, nil
+}
+
+func getBundleRequestData(r *http.Request) (*BundleRequestMetadata, error) {
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		slog.Error("Не удалось разобрать relative_path", "error", err)
+		return nil, err
+	}
+
+	var request bundleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Ошибка разбора тела запроса на скачивание архива", "error", err)
+		return nil, fmt.Errorf("некорректное тело запроса: %w", err)
+	}
+
+	if len(request.Objects) == 0 {
+		return nil, fmt.Errorf("список objects пуст")
+	}
+	if len(request.Objects) > maxBundleObjects {
+		return nil, fmt.Errorf("за один запрос можно скачать не более %d объектов", maxBundleObjects)
+	}
+
+	data := &BundleRequestMetadata{
+		Name:  defaultBundleName,
+		Items: make([]*DownloadRequestMetadata, 0, len(request.Objects)),
+	}
+	if name := filepath.Base(strings.TrimSpace(request.Name)); name != "" && name != "." && name != "/" {
+		data.Name = name
+	}
+
+	password := parseArchivePassword(r)
+	for _, object := range request.Objects {
+		item, err := getIDandSelector(object)
+		if err != nil {
+			return nil, err
+		}
+		item.Key, err = buildObjectKey(relativePath, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		item.Password = password
+		data.Items = append(data.Items, item)
+	}
+
+	return data, nil
+}
+
 func getContentType(fileName string) string {
-	ext := strings.ToLower(filepath.Ext(fileName))
-	defaultContentType := "application/octet-stream"
-	if contentType := mime.TypeByExtension(ext); contentType != "" {
+	if contentType := extensionContentType(fileName); contentType != "" {
 		return contentType
 	}
-	return defaultContentType
+	return sniff.UnknownContentType
+}
+
+// extensionContentType возвращает тип по расширению имени или пустую
+// строку, если расширение ничего не говорит о файле
+func extensionContentType(fileName string) string {
+	if fileName == defaultUploadFileName {
+		return ""
+	}
+
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName)))
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType == sniff.UnknownContentType {
+		return ""
+	}
+	return contentType
 }
 
-func getUploadRequestData(r *http.Request) (*UploadRequestMetadata, error) {
+func getUploadRequestData(r *http.Request, contentTypePolicy string) (*UploadRequestMetadata, error) {
 	objectID, err := parseObjectID(r)
 	if err != nil {
 		slog.Error("Не удалось извлечь object_id", "error", err)
 		return nil, err
 	}
 
+	key, err := parseObjectKey(r, objectID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ объекта", "error", err)
+		return nil, err
+	}
+
 	fileName, err := parseFileNameFromDisposition(r)
 	if err != nil {
 		slog.Warn("Не удалось извлечь имя файла", "error", err)
 		return nil, err
 	}
 
-	contentType := getContentType(fileName)
+	prefix, err := peekBody(r, sniff.PrefixSize)
+	if err != nil {
+		slog.Error("Не удалось прочитать начало тела запроса", "error", err)
+		return nil, err
+	}
+
+	contentType, err := resolveContentType(contentTypePolicy, fileName, r.Header.Get("Content-Type"), prefix)
+	if err != nil {
+		return nil, err
+	}
 	slog.Info("Определен тип содержимого файла", "file_name", fileName, "content_type", contentType)
 
 	contentLength := r.ContentLength
 
 	data := &UploadRequestMetadata{
 		ID:          objectID,
+		Key:         key,
 		FileName:    fileName,
 		ContentType: contentType,
 		Size:        contentLength,
 	}
 
+	if err := setUploadMode(r, data, prefix); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// setUploadMode определяет формат архива по первым байтам содержимого
+// и проверяет, можно ли выполнить распаковку, если она запрошена
+func setUploadMode(r *http.Request, data *UploadRequestMetadata, prefix []byte) error {
+	data.ContainerKind = sniff.DetectContainer(prefix)
+	if data.ContainerKind != sniff.ContainerNone {
+		slog.Info("Загружаемый файл распознан как архив", "file_name", data.FileName, "container_kind", data.ContainerKind)
+	}
+
+	if expand := r.URL.Query().Get("expand"); expand != "" {
+		var err error
+		if data.Expand, err = strconv.ParseBool(expand); err != nil {
+			return fmt.Errorf("некорректное значение expand: %q", expand)
+		}
+	}
+	if data.Expand {
+		if data.ContainerKind == sniff.ContainerNone {
+			return fmt.Errorf("%w: распаковать можно только ZIP или tar", ErrNotArchive)
+		}
+		data.Password = parseArchivePassword(r)
+	}
+	return nil
+}
+
+func getDeleteRequestData(r *http.Request) (*DeleteRequestMetadata, error) {
+	objectID, err := parseObjectID(r)
+	if err != nil {
+		slog.Error("Не удалось извлечь object_id", "error", err)
+		return nil, err
+	}
+
+	key, err := parseObjectKey(r, objectID)
+	if err != nil {
+		slog.Error("Не удалось построить ключ объекта", "error", err)
+		return nil, err
+	}
+
+	data := &DeleteRequestMetadata{
+		ID:        objectID,
+		Key:       key,
+		VersionID: strings.TrimSpace(r.URL.Query().Get(versionIDQueryParam)),
+	}
+
 	return data, nil
 }
 
+func getBatchDeleteRequestData(r *http.Request) (*BatchDeleteRequestMetadata, error) {
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		slog.Error("Не удалось разобрать relative_path", "error", err)
+		return nil, err
+	}
+
+	var request batchDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Ошибка разбора тела запроса на пакетное удаление", "error", err)
+		return nil, fmt.Errorf("некорректное тело запроса: %w", err)
+	}
+
+	if len(request.IDs) == 0 {
+		return nil, fmt.Errorf("список ids пуст")
+	}
+	if len(request.IDs) > maxBatchDeleteObjects {
+		return nil, fmt.Errorf("за один запрос можно удалить не более %d объектов", maxBatchDeleteObjects)
+	}
+
+	data := &BatchDeleteRequestMetadata{Objects: make([]*BatchDeleteObject, 0, len(request.IDs))}
+	for _, objectID := range request.IDs {
+		object := &BatchDeleteObject{ID: objectID}
+		object.Key, err = buildObjectKey(relativePath, strings.TrimSpace(objectID))
+		if err != nil {
+			object.Status = DeleteStatusError
+			object.Error = err.Error()
+		}
+		data.Objects = append(data.Objects, object)
+	}
+
+	return data, nil
+}
+
+// peekBody читает первые n байт тела и возвращает их в начало r.Body,
+// так что последующее чтение тела получает его целиком
+func peekBody(r *http.Request, n int) ([]byte, error) {
+	prefix, body, err := peekReader(r.Body, n)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body = &peekedBody{Reader: body, Closer: r.Body}
+	return prefix, nil
+}
+
+// peekReader читает первые n байт и возвращает reader, который отдает
+// содержимое целиком, вместе с прочитанными байтами
+func peekReader(reader io.Reader, n int) ([]byte, io.Reader, error) {
+	prefix := make([]byte, n)
+	read, err := io.ReadFull(reader, prefix)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, fmt.Errorf("ошибка чтения тела запроса: %w", err)
+	}
+	prefix = prefix[:read]
+
+	return prefix, io.MultiReader(bytes.NewReader(prefix), reader), nil
+}
+
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
 func getSizeMB(size int64) int {
 	return int(size / (1024 * 1024))
 }
 
 func parseObjectID(r *http.Request) (string, error) {
-	objectID := chi.URLParam(r, "object_id")
+	objectID, err := urlParam(r, "object_id")
+	if err != nil {
+		return "", err
+	}
 	if objectID == "" {
 		return "", fmt.Errorf("необходим object_id")
 	}
@@ -112,9 +325,7 @@ func parseFileNameFromDisposition(r *http.Request) (string, error) {
 		_, params, err := mime.ParseMediaType(contentDisposition)
 		if err == nil {
 			if name, ok := params["filename"]; ok && name != "" {
-				name = filepath.Base(name)
-				name = strings.ReplaceAll(name, "..", "")
-				if name != "" {
+				if name = sanitizeFileName(name); name != "" {
 					originalName = name
 					return originalName, nil
 				}
@@ -126,10 +337,18 @@ func parseFileNameFromDisposition(r *http.Request) (string, error) {
 	return originalName, nil
 }
 
-func sendJSONResponse(w http.ResponseWriter, data *UploadRequestMetadata) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+// sanitizeFileName оставляет от имени файла клиента только последний
+// сегмент пути без последовательностей ".."
+func sanitizeFileName(name string) string {
+	name = filepath.Base(name)
+	name = strings.ReplaceAll(name, "..", "")
+	if name == "." || name == string(filepath.Separator) {
+		return ""
+	}
+	return name
+}
 
+func sendJSONResponse(w http.ResponseWriter, data *UploadRequestMetadata) {
 	size := getSizeMB(data.Size)
 
 	response := &objectResponse{
@@ -138,9 +357,27 @@ func sendJSONResponse(w http.ResponseWriter, data *UploadRequestMetadata) {
 		Name:   data.FileName,
 		Type:   data.ContentType,
 		Size:   size,
+		Bytes:  data.Size,
 		// Message: successfulUploadMessage,
 		// UploadDuration: uploadDuration.Seconds(),
 	}
+	sendObjectResponse(w, http.StatusCreated, response)
+}
+
+func sendObjectResponse(w http.ResponseWriter, status int, response *objectResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Ошибка формирования JSON ответа", "error", err)
+	}
+}
+
+func sendBatchDeleteResponse(w http.ResponseWriter, data *BatchDeleteRequestMetadata) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	response := &batchDeleteResponse{Results: data.Objects}
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		slog.Error("Ошибка формирования JSON ответа", "error", err)
 	}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const successfulRestoreStatus = "restored"
+
+// RestoreRequestMetadata заполняется слоем хранилища сведениями
+// о восстановленном объекте
+type RestoreRequestMetadata struct {
+	ID          string
+	Key         string
+	FileName    string
+	ContentType string
+	Size        int64
+}
+
+func (s *Server) Restore(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на восстановление")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		slog.Error("Недопустимый метод запроса")
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	objectID, err := parseObjectID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, err := parseObjectKey(r, objectID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := &RestoreRequestMetadata{ID: objectID, Key: key}
+	if err := loadManager.Restore(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	sendObjectResponse(w, http.StatusOK, &objectResponse{
+		Status: successfulRestoreStatus,
+		ID:     data.ID,
+		Name:   data.FileName,
+		Type:   data.ContentType,
+		Size:   getSizeMB(data.Size),
+		Bytes:  data.Size,
+	})
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
@@ -15,9 +16,25 @@ import (
 )
 
 type LoadManager interface {
-	Upload(r *http.Request, ctx context.Context, data *UploadRequestMetadata) error
+	Upload(body io.Reader, ctx context.Context, data *UploadRequestMetadata) error
 	Download(w http.ResponseWriter, ctx context.Context, data *DownloadRequestMetadata) error
-	Delete(w http.ResponseWriter, r *http.Request, ctx context.Context) error
+	DownloadBundle(w http.ResponseWriter, ctx context.Context, data *BundleRequestMetadata) error
+	DownloadFolder(w http.ResponseWriter, ctx context.Context, data *FolderRequestMetadata) error
+	Stat(ctx context.Context, data *DownloadRequestMetadata) (*ObjectMetadata, error)
+	ListEntries(ctx context.Context, data *DownloadRequestMetadata) ([]*ArchiveEntry, error)
+	Delete(ctx context.Context, data *DeleteRequestMetadata) error
+	DeleteBatch(ctx context.Context, data *BatchDeleteRequestMetadata) error
+	Restore(ctx context.Context, data *RestoreRequestMetadata) error
+	Extract(ctx context.Context, data *ExtractRequestMetadata) error
+	AppendEntries(ctx context.Context, data *AppendEntriesRequestMetadata) error
+	CreateUpload(ctx context.Context, data *ResumableUploadMetadata) error
+	UploadStatus(ctx context.Context, data *ResumableUploadMetadata) error
+	PatchUpload(body io.Reader, ctx context.Context, data *ResumableUploadMetadata) error
+	InitiateMultipartUpload(ctx context.Context, data *MultipartUploadMetadata) error
+	UploadPart(body io.Reader, ctx context.Context, data *MultipartUploadMetadata) error
+	ListParts(ctx context.Context, data *MultipartUploadMetadata) error
+	CompleteMultipartUpload(ctx context.Context, data *MultipartUploadMetadata) error
+	AbortMultipartUpload(ctx context.Context, data *MultipartUploadMetadata) error
 }
 
 // type DBManager interface{
@@ -38,23 +55,58 @@ type LoadManager interface {
 // 	}
 // }
 
+// Storages сопоставляет имя хранилища из сегмента {storage_name} с его LoadManager
+type Storages map[string]LoadManager
+
 type Server struct {
-	ctx         context.Context
-	loadManager LoadManager
+	ctx      context.Context
+	storages Storages
+	upload   config.UploadConfig
 	// dbManager DBManager
 }
 
-func Init(ctx context.Context, lm LoadManager) *Server {
+func Init(ctx context.Context, storages Storages, uploadCfg config.UploadConfig) *Server {
 	return &Server{
-		ctx:         ctx,
-		loadManager: lm,
+		ctx:      ctx,
+		storages: storages,
+		upload:   uploadCfg,
+	}
+}
+
+func (s *Server) loadManagerFor(r *http.Request) (LoadManager, error) {
+	storageName := chi.URLParam(r, "storage_name")
+	loadManager, ok := s.storages[storageName]
+	if !ok {
+		slog.Error("Запрошено неизвестное хранилище", "storage_name", storageName)
+		return nil, fmt.Errorf("%w: %s", ErrStorageNotFound, storageName)
 	}
+	return loadManager, nil
 }
 
 func (s *Server) setupRouter() *chi.Mux {
 	router := chi.NewRouter()
 	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/content", s.Upload)
 	router.Get("/{storage_name}/{relative_path}/objects/{object_id}/content", s.Download)
+	router.Head("/{storage_name}/{relative_path}/objects/{object_id}/content", s.Head)
+	router.Head("/{storage_name}/{relative_path}/objects/{object_id}", s.Head)
+	router.Get("/{storage_name}/{relative_path}/objects/{object_id}/entries", s.Entries)
+	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/entries", s.AppendEntries)
+	router.Get("/{storage_name}/{relative_path}/objects/{object_id}", s.Metadata)
+	router.Delete("/{storage_name}/{relative_path}/objects/{object_id}", s.Delete)
+	router.Post("/{storage_name}/{relative_path}/objects/batch-delete", s.BatchDelete)
+	router.Post("/{storage_name}/{relative_path}/archive", s.DownloadBundle)
+	router.Get("/{storage_name}/{relative_path}/archive", s.DownloadFolder)
+	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/restore", s.Restore)
+	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/extract", s.Extract)
+	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/multipart", s.InitiateMultipartUpload)
+	router.Put("/{storage_name}/{relative_path}/objects/{object_id}/multipart/{upload_id}/parts/{part_number}", s.UploadPart)
+	router.Get("/{storage_name}/{relative_path}/objects/{object_id}/multipart/{upload_id}", s.ListParts)
+	router.Post("/{storage_name}/{relative_path}/objects/{object_id}/multipart/{upload_id}/complete", s.CompleteMultipartUpload)
+	router.Delete("/{storage_name}/{relative_path}/objects/{object_id}/multipart/{upload_id}", s.AbortMultipartUpload)
+	router.Options("/{storage_name}/{relative_path}/uploads", s.TusOptions)
+	router.Post("/{storage_name}/{relative_path}/uploads", s.TusCreate)
+	router.Head("/{storage_name}/{relative_path}/uploads/{upload_id}", s.TusHead)
+	router.Patch("/{storage_name}/{relative_path}/uploads/{upload_id}", s.TusPatch)
 	return router
 }
 
@@ -64,7 +116,7 @@ func (s *Server) Start(cfg config.AppConfig) error {
 
 	slog.Info("запуск HTTP сервера", "address", address)
 	httpServer := &http.Server{
-		Address: address,
+		Addr:    address,
 		Handler: router,
 	}
 
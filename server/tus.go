@@ -0,0 +1,280 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// Протокол tus 1.0: ядро и расширение creation
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation"
+	tusContentType = "application/offset+octet-stream"
+
+	// MaxResumableUploadSize — 10000 частей multipart-загрузки
+	// по 64 МиБ. Часть собирается в памяти, поэтому размер части
+	// растет с длиной загрузки только до 64 МиБ.
+	MaxResumableUploadSize = 10000 * 64 * 1024 * 1024
+)
+
+// ResumableUploadMetadata — загрузка по протоколу tus. UploadID выдается
+// хранилищем при создании, Offset приходит в PATCH и обновляется
+// хранилищем после записи. ID и Key — объект, который появится после
+// получения всех Length байт.
+type ResumableUploadMetadata struct {
+	UploadID     string
+	RelativePath string
+
+	ID          string
+	Key         string
+	FileName    string
+	ContentType string
+	Length      int64
+
+	Offset int64
+	// ChunkSize — Content-Length запроса PATCH или -1, если он неизвестен
+	ChunkSize int64
+	Completed bool
+}
+
+// TusOptions сообщает клиенту поддерживаемые версию и расширения протокола
+func (s *Server) TusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(MaxResumableUploadSize, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TusCreate создает загрузку. Имя файла, тип и object_id берутся
+// из Upload-Metadata, без object_id он генерируется.
+func (s *Server) TusCreate(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Начало обработки запроса на создание возобновляемой загрузки")
+
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getResumableUploadCreateData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if data.Length > MaxResumableUploadSize {
+		http.Error(w, fmt.Sprintf("размер загрузки больше %d байт", MaxResumableUploadSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := loadManager.CreateUpload(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	location := "/" + url.PathEscape(chi.URLParam(r, "storage_name")) + "/" + url.PathEscape(data.RelativePath) + "/uploads/" + data.UploadID
+	w.Header().Set("Location", location)
+	w.Header().Set("X-Object-Id", data.ID)
+	w.WriteHeader(http.StatusCreated)
+	slog.Info("Возобновляемая загрузка создана", "upload_id", data.UploadID, "object_id", data.ID, "length", data.Length)
+}
+
+// TusHead возвращает, сколько байт загрузки уже получено
+func (s *Server) TusHead(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getResumableUploadData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := loadManager.UploadStatus(s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Upload-Offset", strconv.FormatInt(data.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(data.Length, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// TusPatch дописывает данные с позиции Upload-Offset. После получения
+// последнего байта загрузка становится объектом; PATCH завершенной
+// загрузки с Upload-Offset, равным длине, снова отвечает 204.
+func (s *Server) TusPatch(w http.ResponseWriter, r *http.Request) {
+	if !checkTusResumable(w, r) {
+		return
+	}
+
+	if mediaType := r.Header.Get("Content-Type"); mediaType != tusContentType {
+		http.Error(w, fmt.Sprintf("Content-Type должен быть %s", tusContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := getResumableUploadData(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data.Offset, err = strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || data.Offset < 0 {
+		http.Error(w, "некорректный заголовок Upload-Offset", http.StatusBadRequest)
+		return
+	}
+	data.ChunkSize = r.ContentLength
+
+	if err := loadManager.PatchUpload(r.Body, s.ctx, data); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(data.Offset, 10))
+	if data.Completed {
+		w.Header().Set("X-Object-Id", data.ID)
+		slog.Info("Возобновляемая загрузка завершена", "upload_id", data.UploadID, "object_id", data.ID)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkTusResumable проверяет версию протокола клиента
+func checkTusResumable(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "неподдерживаемая версия протокола tus", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+func getResumableUploadCreateData(r *http.Request) (*ResumableUploadMetadata, error) {
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		return nil, fmt.Errorf("Upload-Defer-Length не поддерживается")
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("некорректный заголовок Upload-Length")
+	}
+
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return nil, err
+	}
+
+	objectID := strings.TrimSpace(metadata["object_id"])
+	if objectID == "" {
+		objectID = uuid.NewString()
+	}
+	key, err := buildObjectKey(relativePath, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := sanitizeFileName(firstNonEmpty(metadata["filename"], metadata["name"]))
+	if fileName == "" {
+		fileName = defaultUploadFileName
+	}
+	contentType := strings.TrimSpace(firstNonEmpty(metadata["filetype"], metadata["type"]))
+	if contentType == "" {
+		contentType = getContentType(fileName)
+	} else if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return nil, fmt.Errorf("некорректный тип файла %q в Upload-Metadata: %w", contentType, err)
+	}
+
+	return &ResumableUploadMetadata{
+		RelativePath: relativePath,
+		ID:           objectID,
+		Key:          key,
+		FileName:     fileName,
+		ContentType:  contentType,
+		Length:       length,
+	}, nil
+}
+
+func getResumableUploadData(r *http.Request) (*ResumableUploadMetadata, error) {
+	relativePath, err := parseRelativePath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadID, err := urlParam(r, "upload_id")
+	if err != nil {
+		return nil, err
+	}
+	if err := uuid.Validate(uploadID); err != nil {
+		return nil, fmt.Errorf("некорректный идентификатор загрузки %q", uploadID)
+	}
+
+	return &ResumableUploadMetadata{UploadID: uploadID, RelativePath: relativePath}, nil
+}
+
+// parseUploadMetadata разбирает Upload-Metadata: пары "ключ base64(значение)"
+// через запятую, значение может отсутствовать
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("некорректный заголовок Upload-Metadata")
+		}
+
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("некорректное значение %q в Upload-Metadata: %w", fields[0], err)
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+	return metadata, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
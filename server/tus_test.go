@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestResumableUploadContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		want     string
+		wantErr  bool
+	}{
+		{"declared", "text/csv", "text/csv", false},
+		{"declared with params", "text/plain; charset=utf-8", "text/plain; charset=utf-8", false},
+		{"by extension", "", "text/csv; charset=utf-8", false},
+		{"malformed", "text/", "", true},
+		{"header injection", "text/plain\r\nX-Injected: 1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				data *ResumableUploadMetadata
+				err  error
+			)
+			router := chi.NewRouter()
+			router.Post("/{relative_path}/uploads", func(w http.ResponseWriter, r *http.Request) {
+				data, err = getResumableUploadCreateData(r)
+			})
+
+			metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("report.csv"))
+			if tt.fileType != "" {
+				metadata += ",filetype " + base64.StdEncoding.EncodeToString([]byte(tt.fileType))
+			}
+			request := httptest.NewRequest(http.MethodPost, "/reports/uploads", nil)
+			request.Header.Set("Upload-Length", "10")
+			request.Header.Set("Upload-Metadata", metadata)
+
+			router.ServeHTTP(httptest.NewRecorder(), request)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получен тип %q", data.ContentType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if data.ContentType != tt.want {
+				t.Fatalf("тип %q, ожидался %q", data.ContentType, tt.want)
+			}
+		})
+	}
+}
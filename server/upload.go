@@ -11,13 +11,33 @@ type objectResponse struct {
 	Name   string `json:"name"`
 	Type   string `json:"type"`
 	Size   int    `json:"size_mb"`
+	// Bytes — точный размер: size_mb округляется вниз до мегабайта
+	Bytes int64 `json:"size_bytes"`
 }
 
 type UploadRequestMetadata struct {
 	ID          string
+	Key         string
 	FileName    string
 	ContentType string
 	Size        int64
+
+	// ContainerKind — формат архива, определенный по сигнатуре содержимого
+	ContainerKind string
+
+	// Expand включается параметром expand=true: архив не сохраняется,
+	// а каждый его файл становится объектом с префиксом Key. Manifest
+	// заполняется слоем хранилища.
+	Expand   bool
+	Password ArchivePassword
+	Manifest []*ExpandedObject
+
+	// Metadata — пользовательские метаданные из полей формы
+	Metadata map[string]string
+
+	// CreateOnly запрещает перезаписывать существующий объект: ID
+	// сгенерирован сервисом, и клиент не знает, что под ним лежит
+	CreateOnly bool
 }
 
 func (s *Server) Upload(w http.ResponseWriter, r *http.Request) {
@@ -29,15 +49,31 @@ func (s *Server) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := getUploadRequestData(r)
+	loadManager, err := s.loadManagerFor(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if isMultipartForm(r) {
+		s.uploadMultipart(w, r, loadManager)
+		return
+	}
+
+	data, err := getUploadRequestData(r, s.upload.ContentTypePolicy)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeRequestError(w, err)
+		return
 	}
 
-	if err := s.loadManager.Upload(r, s.ctx, data); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := loadManager.Upload(r.Body, s.ctx, data); err != nil {
+		writeError(w, err)
 		return
 	}
 
+	if data.Expand {
+		sendExpandResponse(w, data)
+		return
+	}
 	sendJSONResponse(w, data)
 }